@@ -0,0 +1,83 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/bench"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var (
+	BenchRunsFlag = cli.IntFlag{
+		Name:  "runs",
+		Usage: "number of times to run every workload",
+		Value: 10,
+	}
+	BenchIterationsFlag = cli.UintFlag{
+		Name:  "iterations",
+		Usage: "number of loop iterations within every workload",
+		Value: 10000,
+	}
+)
+
+var benchCommand = cli.Command{
+	Action:    benchCmd,
+	Name:      "bench",
+	Usage:     "benchmarks the standard workloads",
+	ArgsUsage: "[<workload>...]",
+	Flags:     []cli.Flag{BenchRunsFlag, BenchIterationsFlag},
+	Description: `The bench command times the standard workloads as EVM bytecode and, if an
+ewasm interpreter is configured with --vm.ewasm, as transcompiled ewasm modules.
+Only the named workloads are run if any are given.`,
+}
+
+func benchCmd(ctx *cli.Context) error {
+	config := bench.Config{
+		EVMInterpreter:   ctx.GlobalString(EVMInterpreterFlag.Name),
+		EWASMInterpreter: ctx.GlobalString(EWASMInterpreterFlag.Name),
+	}
+	if config.EVMInterpreter != "" {
+		vm.InitEVMCEVM(config.EVMInterpreter)
+	}
+	if config.EWASMInterpreter != "" {
+		vm.InitEVMCEwasm(config.EWASMInterpreter)
+	}
+	selected := make(map[string]bool)
+	for _, name := range ctx.Args() {
+		selected[name] = true
+	}
+	runs := ctx.Int(BenchRunsFlag.Name)
+
+	fmt.Printf("%-10s %-8s %14s %12s\n", "workload", "format", "time/run", "gas/run")
+	for _, w := range bench.Workloads(uint32(ctx.Uint(BenchIterationsFlag.Name))) {
+		if len(selected) > 0 && !selected[w.Name] {
+			continue
+		}
+		for _, format := range []vm.Format{vm.FormatLegacy, vm.FormatWasm} {
+			result, err := bench.Run(w, format, runs, config)
+			if err != nil {
+				fmt.Printf("%-10s %-8v %s\n", w.Name, format, err)
+				continue
+			}
+			fmt.Printf("%-10s %-8v %14v %12d\n", w.Name, format, result.PerRun(), result.GasUsed)
+		}
+	}
+	return nil
+}
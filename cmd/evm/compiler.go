@@ -20,8 +20,10 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/cmd/evm/internal/compiler"
+	"github.com/ethereum/go-ethereum/params"
 
 	cli "gopkg.in/urfave/cli.v1"
 )
@@ -31,6 +33,10 @@ var compileCommand = cli.Command{
 	Name:      "compile",
 	Usage:     "compiles easm source to evm binary",
 	ArgsUsage: "<file>",
+	Description: `
+Compiles the easm source file to evm binary. If --prestate is given, the code is
+checked against the fork active at the genesis block of its chain config, and
+rejected if it uses instructions that fork doesn't support.`,
 }
 
 func compileCmd(ctx *cli.Context) error {
@@ -46,10 +52,25 @@ func compileCmd(ctx *cli.Context) error {
 		return err
 	}
 
-	bin, err := compiler.Compile(fn, src, debug)
+	var rules *params.Rules
+	if path := ctx.GlobalString(GenesisFlag.Name); path != "" {
+		gen := readGenesis(path)
+		rules = genesisRules(gen.Config, gen.Number)
+	}
+	bin, err := compiler.Compile(fn, src, debug, rules)
 	if err != nil {
 		return err
 	}
 	fmt.Println(bin)
 	return nil
 }
+
+// genesisRules returns the rules of the given chain config at the given block,
+// or nil if there is no chain config to check against.
+func genesisRules(config *params.ChainConfig, number uint64) *params.Rules {
+	if config == nil {
+		return nil
+	}
+	rules := config.Rules(new(big.Int).SetUint64(number))
+	return &rules
+}
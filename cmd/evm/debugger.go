@@ -0,0 +1,120 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+const debuggerHelp = `Commands:
+  step, s       execute the instruction and pause before the next one
+  continue, c   run until the next breakpoint
+  stack         print the stack
+  memory        print the memory
+  help          print this help`
+
+// debugger is an EVM tracer pausing the execution at breakpoints or before
+// every instruction, and reading commands from the standard input to inspect
+// the VM state in the meantime.
+type debugger struct {
+	breakpoints map[uint64]bool // Program counters to pause at, in any contract
+	stepping    bool            // Whether to pause before the next instruction
+	input       *bufio.Reader
+}
+
+// newDebugger creates a debugger pausing at the given comma separated program
+// counters, or before the first instruction if step is set.
+func newDebugger(breakpoints string, step bool) (*debugger, error) {
+	d := &debugger{
+		breakpoints: make(map[uint64]bool),
+		stepping:    step,
+		input:       bufio.NewReader(os.Stdin),
+	}
+	for _, bp := range strings.Split(breakpoints, ",") {
+		if bp = strings.TrimSpace(bp); bp == "" {
+			continue
+		}
+		pc, err := strconv.ParseUint(bp, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid breakpoint %q: %v", bp, err)
+		}
+		d.breakpoints[pc] = true
+	}
+	return d, nil
+}
+
+func (d *debugger) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	return nil
+}
+
+// CaptureState pauses the execution if requested and runs the command prompt
+// until the user resumes it.
+func (d *debugger) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if !d.stepping && !d.breakpoints[pc] {
+		return nil
+	}
+	fmt.Printf("%v: pc=%d op=%v gas=%d cost=%d depth=%d\n", contract.Address().Hex(), pc, op, gas, cost, depth)
+	for {
+		fmt.Print("> ")
+		line, readErr := d.input.ReadString('\n')
+		if readErr != nil && (line == "" || readErr != io.EOF) {
+			// Nobody left to ask, run to completion. A final command without
+			// a trailing newline is still run, detaching on the next read.
+			if readErr != io.EOF {
+				fmt.Printf("\nfailed to read command: %v", readErr)
+			}
+			fmt.Println()
+			d.stepping, d.breakpoints = false, nil
+			return nil
+		}
+		switch strings.TrimSpace(line) {
+		case "step", "s", "":
+			d.stepping = true
+			return nil
+		case "continue", "c":
+			d.stepping = false
+			return nil
+		case "stack":
+			stack.Print()
+		case "memory":
+			memory.Print()
+		case "help":
+			fmt.Println(debuggerHelp)
+		default:
+			fmt.Printf("unknown command %q, try help\n", strings.TrimSpace(line))
+		}
+	}
+}
+
+func (d *debugger) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	fmt.Printf("%v: pc=%d op=%v failed: %v\n", contract.Address().Hex(), pc, op, err)
+	return nil
+}
+
+func (d *debugger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	return nil
+}
@@ -21,10 +21,16 @@ import (
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/params"
 )
 
-func Compile(fn string, src []byte, debug bool) (string, error) {
+// Compile assembles the easm source. If rules are given, instructions missing
+// from that fork are rejected.
+func Compile(fn string, src []byte, debug bool, rules *params.Rules) (string, error) {
 	compiler := asm.NewCompiler(debug)
+	if rules != nil {
+		compiler.SetRules(*rules)
+	}
 	compiler.Feed(asm.Lex(src, debug))
 
 	bin, compileErrors := compiler.Compile()
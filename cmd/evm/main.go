@@ -95,6 +95,18 @@ var (
 		Name:  "json",
 		Usage: "output trace logs in machine readable format (json)",
 	}
+	ProfileFlag = cli.StringFlag{
+		Name:  "profile",
+		Usage: "write the gas and execution count of every opcode, in total and as a flame graph of the call tree, as JSON to the given file",
+	}
+	BreakFlag = cli.StringFlag{
+		Name:  "break",
+		Usage: "comma separated program counters to pause the execution at",
+	}
+	StepFlag = cli.BoolFlag{
+		Name:  "step",
+		Usage: "pause the execution before every instruction",
+	}
 	SenderFlag = cli.StringFlag{
 		Name:  "sender",
 		Usage: "The transaction origin",
@@ -116,6 +128,11 @@ var (
 		Usage: "External EVM configuration (default = built-in interpreter)",
 		Value: "",
 	}
+	EWASMInterpreterFlag = cli.StringFlag{
+		Name:  "vm.ewasm",
+		Usage: "External ewasm configuration",
+		Value: "",
+	}
 )
 
 func init() {
@@ -135,17 +152,23 @@ func init() {
 		StatDumpFlag,
 		GenesisFlag,
 		MachineFlag,
+		ProfileFlag,
+		BreakFlag,
+		StepFlag,
 		SenderFlag,
 		ReceiverFlag,
 		DisableMemoryFlag,
 		DisableStackFlag,
 		EVMInterpreterFlag,
+		EWASMInterpreterFlag,
 	}
 	app.Commands = []cli.Command{
+		benchCommand,
 		compileCommand,
 		disasmCommand,
 		runCommand,
 		stateTestCommand,
+		transcompileCommand,
 	}
 }
 
@@ -95,6 +95,26 @@ func runCmd(ctx *cli.Context) error {
 	} else {
 		debugLogger = vm.NewStructLogger(logconfig)
 	}
+	var profiler *vm.OpcodeProfiler
+	if ctx.GlobalString(ProfileFlag.Name) != "" {
+		if tracer != nil {
+			utils.Fatalf("--%s cannot be combined with --%s or --%s", ProfileFlag.Name, MachineFlag.Name, DebugFlag.Name)
+		}
+		profiler = vm.NewOpcodeProfiler(true)
+	}
+	var debug *debugger
+	if ctx.GlobalString(BreakFlag.Name) != "" || ctx.GlobalBool(StepFlag.Name) {
+		if tracer != nil || profiler != nil {
+			utils.Fatalf("--%s and --%s cannot be combined with other tracing flags", BreakFlag.Name, StepFlag.Name)
+		}
+		if ctx.GlobalString(CodeFileFlag.Name) == "-" {
+			utils.Fatalf("--%s and --%s read commands from stdin, load the code from a file", BreakFlag.Name, StepFlag.Name)
+		}
+		var err error
+		if debug, err = newDebugger(ctx.GlobalString(BreakFlag.Name), ctx.GlobalBool(StepFlag.Name)); err != nil {
+			utils.Fatalf("%v", err)
+		}
+	}
 	if ctx.GlobalString(GenesisFlag.Name) != "" {
 		gen := readGenesis(ctx.GlobalString(GenesisFlag.Name))
 		genesisConfig = gen
@@ -156,7 +176,7 @@ func runCmd(ctx *cli.Context) error {
 		if err != nil {
 			return err
 		}
-		bin, err := compiler.Compile(fn, src, false)
+		bin, err := compiler.Compile(fn, src, false, genesisRules(chainConfig, genesisConfig.Number))
 		if err != nil {
 			return err
 		}
@@ -183,6 +203,15 @@ func runCmd(ctx *cli.Context) error {
 		},
 	}
 
+	if profiler != nil {
+		runtimeConfig.EVMConfig.Tracer = profiler
+		runtimeConfig.EVMConfig.Debug = true
+	}
+	if debug != nil {
+		runtimeConfig.EVMConfig.Tracer = debug
+		runtimeConfig.EVMConfig.Debug = true
+	}
+
 	if runtimeConfig.EVMConfig.EVMInterpreter != "" {
 		vm.InitEVMCEVM(runtimeConfig.EVMConfig.EVMInterpreter)
 	}
@@ -235,6 +264,19 @@ func runCmd(ctx *cli.Context) error {
 		f.Close()
 	}
 
+	if profiler != nil {
+		profile, err := json.MarshalIndent(struct {
+			Opcodes []vm.OpcodeStat  `json:"opcodes"`
+			Frames  *vm.ProfileFrame `json:"frames"`
+		}{profiler.Stats(), profiler.Frames()}, "", "  ")
+		if err != nil {
+			utils.Fatalf("Failed to encode opcode profile: %v", err)
+		}
+		if err := ioutil.WriteFile(ctx.GlobalString(ProfileFlag.Name), profile, 0644); err != nil {
+			utils.Fatalf("Failed to write opcode profile: %v", err)
+		}
+	}
+
 	if ctx.GlobalBool(DebugFlag.Name) {
 		if debugLogger != nil {
 			fmt.Fprintln(os.Stderr, "#### TRACE ####")
@@ -0,0 +1,102 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/evm2wasm"
+	"github.com/ethereum/go-ethereum/core/vm/ewasm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/params"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var transcompileCommand = cli.Command{
+	Action:    transcompileCmd,
+	Name:      "transcompile",
+	Usage:     "transcompiles evm binary to ewasm",
+	ArgsUsage: "<file>",
+	Description: `The transcompile command translates EVM code into an ewasm contract.
+The wasm binary is printed, unless an ewasm interpreter is configured with
+--vm.ewasm, in which case the contract is run with the given --input instead.`,
+}
+
+func transcompileCmd(ctx *cli.Context) error {
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("filename required")
+	}
+	in, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	bin, err := evm2wasm.Transcompile(common.FromHex(strings.TrimSpace(string(in))))
+	if err != nil {
+		return err
+	}
+	ewasmInterpreter := ctx.GlobalString(EWASMInterpreterFlag.Name)
+	if ewasmInterpreter == "" {
+		fmt.Printf("0x%x\n", bin)
+		return nil
+	}
+	// Deploy the contract the way the sentinel would and call into it
+	code, err := ewasm.ValidateAndMeter(bin)
+	if err != nil {
+		return err
+	}
+	vm.InitEVMCEwasm(ewasmInterpreter)
+
+	var (
+		sender   = common.BytesToAddress([]byte("sender"))
+		receiver = common.BytesToAddress([]byte("receiver"))
+	)
+	if ctx.GlobalString(SenderFlag.Name) != "" {
+		sender = common.HexToAddress(ctx.GlobalString(SenderFlag.Name))
+	}
+	if ctx.GlobalString(ReceiverFlag.Name) != "" {
+		receiver = common.HexToAddress(ctx.GlobalString(ReceiverFlag.Name))
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	statedb.CreateAccount(sender)
+	statedb.SetCode(receiver, code)
+
+	chainConfig := *params.AllEthashProtocolChanges
+	chainConfig.EWASMBlock = new(big.Int)
+
+	ret, _, err := runtime.Call(receiver, common.FromHex(ctx.GlobalString(InputFlag.Name)), &runtime.Config{
+		ChainConfig: &chainConfig,
+		Origin:      sender,
+		State:       statedb,
+		GasLimit:    ctx.GlobalUint64(GasFlag.Name),
+		EVMConfig: vm.Config{
+			EWASMInterpreter: ewasmInterpreter,
+		},
+	})
+	fmt.Printf("0x%x\n", ret)
+	if err != nil {
+		fmt.Printf(" error: %v\n", err)
+	}
+	return nil
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
@@ -171,6 +172,26 @@ Remove blockchain and state databases`,
 		Description: `
 The arguments are interpreted as block numbers or hashes.
 Use "ethereum dump 0" to dump the genesis block.`,
+	}
+	opcodeProfileCommand = cli.Command{
+		Action:    utils.MigrateFlags(opcodeProfile),
+		Name:      "opcodeprofile",
+		Usage:     "Replay a range of blocks and report opcode frequencies",
+		ArgsUsage: "<firstBlockNum> <lastBlockNum>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The opcodeprofile command re-executes every block of the given range on top of
+its parent state, which therefore has to be available, and prints the
+execution count and gas of every opcode as JSON. The share is relative to the
+gas charged for all opcodes; the gas of call opcodes leaves out the gas forwarded
+to the callee. wasm contracts are executed by the ewasm engine and are not broken
+down by opcode, the frames field counts the executed contract frames by code
+format, legacy or wasm, instead.`,
 	}
 	inspectCommand = cli.Command{
 		Action:    utils.MigrateFlags(inspect),
@@ -542,6 +563,74 @@ func dump(ctx *cli.Context) error {
 	return nil
 }
 
+func opcodeProfile(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("This command requires two arguments.")
+	}
+	first, ferr := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+	last, lerr := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	if ferr != nil || lerr != nil || first == 0 || first > last {
+		utils.Fatalf("Invalid block range")
+	}
+	stack := makeFullNode(ctx)
+	defer stack.Close()
+
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	var (
+		profiler = vm.NewOpcodeProfiler(false)
+		counter  = vm.NewOpcodeCounter()
+		vmcfg    = vm.Config{Debug: true, Tracer: profiler, OpcodeCounter: counter}
+		gasUsed  uint64
+	)
+	for num := first; num <= last; num++ {
+		block := chain.GetBlockByNumber(num)
+		if block == nil {
+			utils.Fatalf("Block %d not found", num)
+		}
+		parent := chain.GetBlock(block.ParentHash(), num-1)
+		statedb, err := chain.StateAt(parent.Root())
+		if err != nil {
+			utils.Fatalf("State of block %d unavailable: %v", num-1, err)
+		}
+		if _, _, _, err := chain.Processor().Process(block, statedb, vmcfg); err != nil {
+			utils.Fatalf("Failed to replay block %d: %v", num, err)
+		}
+		gasUsed += block.GasUsed()
+	}
+	type opcodeShare struct {
+		vm.OpcodeStat
+		Share float64 `json:"share"`
+	}
+	report := struct {
+		Blocks  uint64            `json:"blocks"`
+		GasUsed uint64            `json:"gasUsed"`
+		Frames  map[string]uint64 `json:"frames"`
+		Opcodes []opcodeShare     `json:"opcodes"`
+	}{Blocks: last - first + 1, GasUsed: gasUsed, Frames: counter.Formats()}
+
+	stats := profiler.Stats()
+
+	var opcodeGas uint64
+	for _, stat := range stats {
+		opcodeGas += stat.Gas
+	}
+	for _, stat := range stats {
+		share := opcodeShare{OpcodeStat: stat}
+		if opcodeGas > 0 {
+			share.Share = float64(stat.Gas) / float64(opcodeGas)
+		}
+		report.Opcodes = append(report.Opcodes, share)
+	}
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode profile: %v", err)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
 func inspect(ctx *cli.Context) error {
 	node, _ := makeConfigNode(ctx)
 	defer node.Close()
@@ -135,6 +135,7 @@ var (
 		utils.NodeKeyHexFlag,
 		utils.DeveloperFlag,
 		utils.DeveloperPeriodFlag,
+		utils.DeveloperEWASMFlag,
 		utils.TestnetFlag,
 		utils.RinkebyFlag,
 		utils.GoerliFlag,
@@ -147,6 +148,7 @@ var (
 		utils.GpoPercentileFlag,
 		utils.EWASMInterpreterFlag,
 		utils.EVMInterpreterFlag,
+		utils.VMCountOpcodesFlag,
 		configFileFlag,
 	}
 
@@ -207,6 +209,7 @@ func init() {
 		copydbCommand,
 		removedbCommand,
 		dumpCommand,
+		opcodeProfileCommand,
 		inspectCommand,
 		// See accountcmd.go:
 		accountCommand,
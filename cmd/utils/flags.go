@@ -161,6 +161,10 @@ var (
 		Name:  "dev.period",
 		Usage: "Block period to use in developer mode (0 = mine only if transaction pending)",
 	}
+	DeveloperEWASMFlag = cli.BoolFlag{
+		Name:  "dev.ewasm",
+		Usage: "Activate ewasm from genesis in developer mode (requires --vm.ewasm)",
+	}
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity",
 		Usage: "Custom node name",
@@ -737,6 +741,10 @@ var (
 		Usage: "External EVM configuration (default = built-in interpreter)",
 		Value: "",
 	}
+	VMCountOpcodesFlag = cli.BoolFlag{
+		Name:  "vm.countopcodes",
+		Usage: "Count the executed opcodes, reported by debug_opcodeCounts",
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -1456,6 +1464,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		cfg.EVMInterpreter = ctx.GlobalString(EVMInterpreterFlag.Name)
 		vm.InitEVMCEVM(cfg.EVMInterpreter)
 	}
+	if ctx.GlobalIsSet(VMCountOpcodesFlag.Name) {
+		cfg.CountOpcodes = ctx.GlobalBool(VMCountOpcodesFlag.Name)
+	}
 	if ctx.GlobalIsSet(RPCGlobalGasCap.Name) {
 		cfg.RPCGasCap = new(big.Int).SetUint64(ctx.GlobalUint64(RPCGlobalGasCap.Name))
 	}
@@ -1499,7 +1510,15 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		}
 		log.Info("Using developer account", "address", developer.Address)
 
-		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address)
+		period := uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name))
+		if ctx.GlobalBool(DeveloperEWASMFlag.Name) {
+			if !ctx.GlobalIsSet(EWASMInterpreterFlag.Name) {
+				Fatalf("Flag --%s requires --%s", DeveloperEWASMFlag.Name, EWASMInterpreterFlag.Name)
+			}
+			cfg.Genesis = core.DeveloperEWASMGenesisBlock(period, developer.Address)
+		} else {
+			cfg.Genesis = core.DeveloperGenesisBlock(period, developer.Address)
+		}
 		if !ctx.GlobalIsSet(MinerGasPriceFlag.Name) && !ctx.GlobalIsSet(MinerLegacyGasPriceFlag.Name) {
 			cfg.Miner.GasPrice = big.NewInt(1)
 		}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 // Compiler contains information about the parsed source
@@ -36,6 +37,8 @@ type Compiler struct {
 
 	pc, pos int
 
+	opcodes map[vm.OpCode]bool // Instructions available in the target fork, nil for all
+
 	debug bool
 }
 
@@ -47,6 +50,15 @@ func NewCompiler(debug bool) *Compiler {
 	}
 }
 
+// SetRules restricts the compiler to the instructions available under the
+// given chain rules, rejecting programs that could never run on that fork.
+func (c *Compiler) SetRules(rules params.Rules) {
+	c.opcodes = make(map[vm.OpCode]bool)
+	for _, info := range vm.ActiveOpcodes(rules) {
+		c.opcodes[info.Op] = true
+	}
+}
+
 // Feed feeds tokens in to ch and are interpreted by
 // the compiler.
 //
@@ -133,6 +145,10 @@ func (c *Compiler) compileLine() error {
 	case eof:
 		return nil
 	case element:
+		if err := c.checkInstruction(lvalue); err != nil {
+			c.skipLine()
+			return err
+		}
 		if err := c.compileElement(lvalue); err != nil {
 			return err
 		}
@@ -151,6 +167,38 @@ func (c *Compiler) compileLine() error {
 	return nil
 }
 
+// checkInstruction returns an error if the element is not a known instruction
+// or, if the compiler is restricted to a fork, not available in that fork.
+// Pushes and jumps are available in every fork.
+func (c *Compiler) checkInstruction(element token) error {
+	if isPush(element.text) || isJump(element.text) {
+		return nil
+	}
+	op := toBinary(element.text)
+	if op.String() != strings.ToUpper(element.text) {
+		return compileErr(element, element.text, "instruction")
+	}
+	if c.opcodes != nil && !c.opcodes[op] {
+		return compileErr(element, element.text, "instruction available in the target fork")
+	}
+	return nil
+}
+
+// skipLine moves past the remaining tokens of the current line.
+func (c *Compiler) skipLine() {
+	for c.pos < len(c.tokens) {
+		switch c.tokens[c.pos].typ {
+		case lineEnd:
+			c.pos++
+			return
+		case eof:
+			c.pos = len(c.tokens)
+			return
+		}
+		c.pos++
+	}
+}
+
 // compileNumber compiles the number to bytes
 func (c *Compiler) compileNumber(element token) (int, error) {
 	num := math.MustParseBig256(element.text).Bytes()
@@ -0,0 +1,66 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the compiler only accepts the instructions of the target fork.
+func TestCompilerRules(t *testing.T) {
+	tests := []struct {
+		src   string
+		rules *params.Rules
+		fail  bool
+	}{
+		{"push 1\npush 2\nshl\n", nil, false},
+		{"push 1\npush 2\nshl\n", &params.Rules{IsConstantinople: true}, false},
+		{"push 1\npush 2\nshl\n", &params.Rules{IsByzantium: true}, true},
+		{"push0\n", &params.Rules{IsConstantinople: true}, true},
+		{"push0\n", &params.Rules{IsEIP3855: true}, false},
+		{"foo\n", &params.Rules{IsEIP3855: true}, true},
+		{"foo\n", nil, true},
+		{"push 1\nfoo\npush 2\n", nil, true},
+		{"jump @end\nend:\nstop\n", &params.Rules{}, false},
+	}
+	for i, tt := range tests {
+		compiler := NewCompiler(false)
+		if tt.rules != nil {
+			compiler.SetRules(*tt.rules)
+		}
+		compiler.Feed(Lex([]byte(tt.src), false))
+		bin, errs := compiler.Compile()
+		if (len(errs) > 0) != tt.fail {
+			t.Errorf("test %d: failure mismatch: have %v, want failure %v", i, errs, tt.fail)
+		}
+		if !tt.fail {
+			continue
+		}
+		// Rejected instructions must be reported once and leave no bytes behind
+		if len(errs) != 1 {
+			t.Errorf("test %d: error count mismatch: have %v, want 1", i, errs)
+		}
+		if code, _ := hex.DecodeString(bin); bytes.IndexByte(code, byte(vm.STOP)) >= 0 {
+			t.Errorf("test %d: rejected instruction emitted: %s", i, bin)
+		}
+	}
+}
@@ -19,6 +19,7 @@ package forkid
 import (
 	"bytes"
 	"math"
+	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -113,6 +114,31 @@ func TestCreation(t *testing.T) {
 	}
 }
 
+// TestExperimentalForks tests that the experimental forks of this tree are part
+// of the fork ID, so that nodes disagreeing on their schedule are told apart.
+func TestExperimentalForks(t *testing.T) {
+	config := *params.MainnetChainConfig
+	config.EWASMBlock = big.NewInt(8000000)
+	config.EIP3855Block = big.NewInt(9000000)
+
+	tests := []struct {
+		head uint64
+		next uint64
+	}{
+		{7999999, 8000000},
+		{8000000, 9000000},
+		{9000000, 0},
+	}
+	for i, tt := range tests {
+		if have := newID(&config, params.MainnetGenesisHash, tt.head); have.Next != tt.next {
+			t.Errorf("test %d: next fork mismatch: have %d, want %d", i, have.Next, tt.next)
+		}
+	}
+	if newID(&config, params.MainnetGenesisHash, 8000000) == newID(params.MainnetChainConfig, params.MainnetGenesisHash, 8000000) {
+		t.Errorf("ewasm fork not reflected in the fork ID")
+	}
+}
+
 // TestValidation tests that a local peer correctly validates and accepts a remote
 // fork ID.
 func TestValidation(t *testing.T) {
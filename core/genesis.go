@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
@@ -199,6 +200,9 @@ func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig
 
 	// Get the existing chain configuration.
 	newcfg := genesis.configOrDefault(stored)
+	if err := newcfg.CheckConfigForkOrder(); err != nil {
+		return newcfg, common.Hash{}, err
+	}
 	storedcfg := rawdb.ReadChainConfig(db, stored)
 	if storedcfg == nil {
 		log.Warn("Found genesis block without chain config")
@@ -394,6 +398,19 @@ func DeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
 	}
 }
 
+// DeveloperEWASMGenesisBlock returns the 'geth --dev --dev.ewasm' genesis block,
+// which activates ewasm from genesis on top of the developer network.
+func DeveloperEWASMGenesisBlock(period uint64, faucet common.Address) *Genesis {
+	genesis := DeveloperGenesisBlock(period, faucet)
+	genesis.Config.EWASMBlock = new(big.Int)
+
+	// Pre-fund the ewasm system contracts just like the other precompiles
+	for _, addr := range vm.ActivePrecompiles(genesis.Config.Rules(new(big.Int))) {
+		genesis.Alloc[addr] = GenesisAccount{Balance: big.NewInt(1)}
+	}
+	return genesis
+}
+
 func decodePrealloc(data string) GenesisAlloc {
 	var p []struct{ Addr, Balance *big.Int }
 	if err := rlp.NewStream(strings.NewReader(data), 0).Decode(&p); err != nil {
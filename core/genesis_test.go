@@ -162,3 +162,27 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+func TestDeveloperEWASMGenesis(t *testing.T) {
+	faucet := common.HexToAddress("0xfaced")
+	genesis := DeveloperEWASMGenesisBlock(0, faucet)
+
+	if !genesis.Config.IsEWASM(new(big.Int)) {
+		t.Errorf("ewasm not active at genesis")
+	}
+	if err := genesis.Config.CheckConfigForkOrder(); err != nil {
+		t.Errorf("invalid fork ordering: %v", err)
+	}
+	for addr := range vm.PrecompiledContractsEWASM {
+		if _, ok := genesis.Alloc[addr]; !ok {
+			t.Errorf("ewasm contract %x not pre-funded", addr)
+		}
+	}
+	if _, ok := genesis.Alloc[faucet]; !ok {
+		t.Errorf("faucet not pre-funded")
+	}
+	// The shared developer configuration must not be modified
+	if DeveloperGenesisBlock(0, faucet).Config.EWASMBlock != nil {
+		t.Errorf("developer genesis config modified")
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package bench contains standard workloads for comparing the performance of
+// executing contracts as EVM bytecode and as ewasm modules.
+//
+// Every workload is written in EVM bytecode. The ewasm flavour is derived with
+// the evm2wasm transcompiler, so workloads relying on opcodes it can't handle
+// are only available as EVM bytecode.
+package bench
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/evm2wasm"
+	"github.com/ethereum/go-ethereum/core/vm/ewasm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	ErrUnknownFormat      = errors.New("bench: unknown code format")
+	ErrNoEWASMInterpreter = errors.New("bench: no ewasm interpreter configured")
+)
+
+// Workload is a benchmark program.
+type Workload struct {
+	Name string
+	Code []byte // EVM bytecode of the program
+}
+
+// Workloads returns the standard workloads, each repeating its core operation
+// the given number of times:
+//
+//   - keccak:  hashes a word of memory
+//   - memcopy: shifts eight words of memory by one word
+//   - storage: writes and reads back a fresh slot, overwriting a shared one
+func Workloads(iterations uint32) []Workload {
+	var memcopy []byte
+	for offset := 0; offset < 8*32; offset += 32 {
+		memcopy = append(memcopy, byte(vm.PUSH2), byte(offset>>8), byte(offset), byte(vm.MLOAD))
+		memcopy = append(memcopy, byte(vm.PUSH2), byte((offset+32)>>8), byte(offset+32), byte(vm.MSTORE))
+	}
+	return []Workload{
+		{"keccak", loop(iterations,
+			byte(vm.DUP1), byte(vm.PUSH1), 0, byte(vm.MSTORE),
+			byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.SHA3), byte(vm.POP),
+		)},
+		{"memcopy", loop(iterations, append([]byte{
+			byte(vm.DUP1), byte(vm.PUSH1), 0, byte(vm.MSTORE),
+		}, memcopy...)...)},
+		{"storage", loop(iterations,
+			byte(vm.DUP1), byte(vm.DUP1), byte(vm.SSTORE),
+			byte(vm.DUP1), byte(vm.SLOAD), byte(vm.POP),
+			byte(vm.DUP1), byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		)},
+	}
+}
+
+// loop wraps the body into a loop counting down from the given number of
+// iterations. The body finds the counter on top of the stack and has to leave
+// the stack as it found it.
+func loop(iterations uint32, body ...byte) []byte {
+	code := []byte{byte(vm.PUSH4), 0, 0, 0, 0, byte(vm.JUMPDEST)}
+	binary.BigEndian.PutUint32(code[1:], iterations)
+
+	code = append(code, body...)
+	return append(code,
+		byte(vm.PUSH1), 1, byte(vm.SWAP1), byte(vm.SUB),
+		byte(vm.DUP1), byte(vm.PUSH1), 5, byte(vm.JUMPI),
+		byte(vm.POP), byte(vm.STOP),
+	)
+}
+
+// Compile returns the code of the workload in the given format, as it would be
+// deployed on chain.
+func (w Workload) Compile(format vm.Format) ([]byte, error) {
+	switch format {
+	case vm.FormatLegacy:
+		return w.Code, nil
+	case vm.FormatWasm:
+		bin, err := evm2wasm.Transcompile(w.Code)
+		if err != nil {
+			return nil, err
+		}
+		return ewasm.ValidateAndMeter(bin)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// Config selects the interpreters to run the workloads with. The external
+// interpreters have to be loaded through vm.InitEVMCEVM and vm.InitEVMCEwasm
+// beforehand.
+type Config struct {
+	EVMInterpreter   string // External EVM, the built-in interpreter if empty
+	EWASMInterpreter string // External ewasm VM, mandatory for wasm workloads
+}
+
+// Result is the outcome of benchmarking a workload.
+type Result struct {
+	Workload string
+	Format   vm.Format
+	Runs     int
+	Elapsed  time.Duration // Total execution time of all runs
+	GasUsed  uint64        // Gas used by a single run
+}
+
+// PerRun returns the average execution time of a single run.
+func (r Result) PerRun() time.Duration {
+	if r.Runs == 0 {
+		return 0
+	}
+	return r.Elapsed / time.Duration(r.Runs)
+}
+
+// Run executes the workload in the given format the requested number of
+// times. Every run starts out from a fresh state, the setup of which doesn't
+// count towards the elapsed time.
+func Run(w Workload, format vm.Format, runs int, config Config) (Result, error) {
+	runner, err := NewRunner(w, format, config)
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Workload: w.Name, Format: format, Runs: runs}
+	for i := 0; i < runs; i++ {
+		runner.Prepare()
+
+		start := time.Now()
+		gasUsed, err := runner.Exec()
+		result.Elapsed += time.Since(start)
+		if err != nil {
+			return Result{}, err
+		}
+		result.GasUsed = gasUsed
+	}
+	return result, nil
+}
+
+// Runner executes a workload in a given format over and over again, keeping
+// the setup of every run apart so that it can be left out of measurements.
+type Runner struct {
+	code        []byte
+	chainConfig params.ChainConfig
+	config      Config
+	cfg         *runtime.Config // Environment of the prepared run
+}
+
+// NewRunner compiles the workload into the given format and readies it for
+// execution.
+func NewRunner(w Workload, format vm.Format, config Config) (*Runner, error) {
+	code, err := w.Compile(format)
+	if err != nil {
+		return nil, err
+	}
+	r := &Runner{code: code, chainConfig: *params.AllEthashProtocolChanges, config: config}
+	if format == vm.FormatWasm {
+		if config.EWASMInterpreter == "" {
+			return nil, ErrNoEWASMInterpreter
+		}
+		r.chainConfig.EWASMBlock = new(big.Int)
+	}
+	return r, nil
+}
+
+// Prepare sets up a fresh state with the workload deployed for the next run.
+func (r *Runner) Prepare() {
+	r.cfg = &runtime.Config{
+		ChainConfig: &r.chainConfig,
+		GasLimit:    math.MaxUint64,
+		EVMConfig: vm.Config{
+			EVMInterpreter:   r.config.EVMInterpreter,
+			EWASMInterpreter: r.config.EWASMInterpreter,
+		},
+	}
+	r.cfg.State, _ = state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	r.cfg.State.SetCode(runnerAddress, r.code)
+}
+
+// Exec runs the workload on the state set up by the last call to Prepare,
+// returning the gas it used.
+func (r *Runner) Exec() (uint64, error) {
+	_, leftOverGas, err := runtime.Call(runnerAddress, nil, r.cfg)
+	if err != nil {
+		return 0, err
+	}
+	return r.cfg.GasLimit - leftOverGas, nil
+}
+
+// runnerAddress is the address workloads are deployed to.
+var runnerAddress = common.BytesToAddress([]byte("contract"))
@@ -0,0 +1,81 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bench
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+var ewasmInterpreter = flag.String("vm.ewasm", "", "External ewasm configuration to benchmark wasm workloads with")
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if *ewasmInterpreter != "" {
+		vm.InitEVMCEwasm(*ewasmInterpreter)
+	}
+	os.Exit(m.Run())
+}
+
+// Tests that the standard workloads run to completion as EVM bytecode and
+// translate to wasm.
+func TestWorkloads(t *testing.T) {
+	for _, w := range Workloads(16) {
+		result, err := Run(w, vm.FormatLegacy, 1, Config{})
+		if err != nil {
+			t.Errorf("%s: failed to run: %v", w.Name, err)
+		} else if result.GasUsed == 0 {
+			t.Errorf("%s: no gas used", w.Name)
+		}
+		if _, err := w.Compile(vm.FormatWasm); err != nil {
+			t.Errorf("%s: failed to transcompile: %v", w.Name, err)
+		}
+		if _, err := Run(w, vm.FormatWasm, 1, Config{}); err != ErrNoEWASMInterpreter {
+			t.Errorf("%s: ran without interpreter: %v", w.Name, err)
+		}
+	}
+}
+
+// Runs every workload in every format it's available in. Wasm workloads are
+// only run if an ewasm interpreter is configured through -vm.ewasm.
+func BenchmarkWorkloads(b *testing.B) {
+	for _, w := range Workloads(1000) {
+		for _, format := range []vm.Format{vm.FormatLegacy, vm.FormatWasm} {
+			w, format := w, format
+			b.Run(fmt.Sprintf("%s/%v", w.Name, format), func(b *testing.B) {
+				runner, err := NewRunner(w, format, Config{EWASMInterpreter: *ewasmInterpreter})
+				if err != nil {
+					b.Skip(err)
+				}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					runner.Prepare()
+					b.StartTimer()
+
+					if _, err := runner.Exec(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
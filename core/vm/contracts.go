@@ -17,13 +17,19 @@
 package vm
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"errors"
+	"fmt"
 	"math/big"
+	"sort"
+	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/vm/ewasm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
 	"github.com/ethereum/go-ethereum/crypto/bn256"
 	"github.com/ethereum/go-ethereum/params"
 	"golang.org/x/crypto/ripemd160"
@@ -59,6 +65,124 @@ var PrecompiledContractsByzantium = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{8}): &bn256Pairing{},
 }
 
+// PrecompiledContractsBLS contains the BLS12-381 pre-compiled contracts added
+// by EIP-2537.
+var PrecompiledContractsBLS = map[common.Address]PrecompiledContract{
+	common.BytesToAddress([]byte{10}): &bls12381G1Add{},
+	common.BytesToAddress([]byte{11}): &bls12381G1Mul{},
+	common.BytesToAddress([]byte{12}): &bls12381G1MultiExp{},
+	common.BytesToAddress([]byte{13}): &bls12381G2Add{},
+	common.BytesToAddress([]byte{14}): &bls12381G2Mul{},
+	common.BytesToAddress([]byte{15}): &bls12381G2MultiExp{},
+	common.BytesToAddress([]byte{16}): &bls12381Pairing{},
+	common.BytesToAddress([]byte{17}): &bls12381MapG1{},
+	common.BytesToAddress([]byte{18}): &bls12381MapG2{},
+}
+
+// SentinelAddress is the address of the ewasm sentinel system contract, which
+// every wasm module is routed through before being executed or deployed. The
+// ewasm contracts live from 0x0100 on, clear of the addresses claimed by
+// Ethereum precompiles.
+var SentinelAddress = common.BytesToAddress([]byte{1, 0})
+
+// PrecompiledContractsEWASM contains the ewasm system contracts and the 256 bit
+// integer arithmetic wasm contracts would otherwise have to implement
+// themselves.
+var PrecompiledContractsEWASM = map[common.Address]PrecompiledContract{
+	SentinelAddress:                     &sentinel{},
+	common.BytesToAddress([]byte{1, 1}): &bignumMul256{},
+	common.BytesToAddress([]byte{1, 2}): &bignumMulMod256{},
+	common.BytesToAddress([]byte{1, 3}): &bignumAddMod256{},
+}
+
+// precompiledSet is a set of pre-compiled contracts along with the predicate
+// deciding whether the fork introducing it is active.
+type precompiledSet struct {
+	active    func(rules params.Rules) bool
+	contracts map[common.Address]PrecompiledContract
+}
+
+// precompiledSets is the registry of pre-compiled contract sets. The contracts
+// of all active sets are available side by side, so the sets active under the
+// same rules must not share any address.
+var precompiledSets = []precompiledSet{
+	{func(rules params.Rules) bool { return !rules.IsByzantium }, PrecompiledContractsHomestead},
+	{func(rules params.Rules) bool { return rules.IsByzantium }, PrecompiledContractsByzantium},
+	{func(rules params.Rules) bool { return rules.IsEIP2537 }, PrecompiledContractsBLS},
+	{func(rules params.Rules) bool { return rules.IsEWASM }, PrecompiledContractsEWASM},
+}
+
+// precompilesInUse is set once the first EVM is created, after which the
+// registry must not change anymore.
+var precompilesInUse int32
+
+// RegisterPrecompiledContracts adds a set of pre-compiled contracts to use
+// whenever active reports true for the chain rules in effect, alongside the
+// builtin ones. It is meant for experimental forks and must be called during
+// initialization, before any EVM is created.
+//
+// The new contracts may not reuse an address of any registered set, whether
+// or not the sets can be active at the same time.
+func RegisterPrecompiledContracts(active func(rules params.Rules) bool, contracts map[common.Address]PrecompiledContract) {
+	if atomic.LoadInt32(&precompilesInUse) != 0 {
+		panic("vm: precompiled contracts registered after EVM creation")
+	}
+	for _, set := range precompiledSets {
+		for addr := range contracts {
+			if set.contracts[addr] != nil {
+				panic(fmt.Sprintf("vm: precompiled contract already registered at %x", addr))
+			}
+		}
+	}
+	precompiledSets = append(precompiledSets, precompiledSet{active, contracts})
+}
+
+// precompiledContract returns the pre-compiled contract at the given address
+// under the given chain rules, or nil if there is none.
+func precompiledContract(rules params.Rules, addr common.Address) PrecompiledContract {
+	for _, set := range precompiledSets {
+		if set.active(rules) {
+			if p := set.contracts[addr]; p != nil {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// precompiledContracts merges the sets of pre-compiled contracts active under
+// the given chain rules, panicking if two of them collide.
+func precompiledContracts(rules params.Rules) map[common.Address]PrecompiledContract {
+	contracts := make(map[common.Address]PrecompiledContract)
+	for _, set := range precompiledSets {
+		if !set.active(rules) {
+			continue
+		}
+		for addr, p := range set.contracts {
+			if contracts[addr] != nil {
+				panic(fmt.Sprintf("vm: active precompiled contracts collide at %x", addr))
+			}
+			contracts[addr] = p
+		}
+	}
+	return contracts
+}
+
+// ActivePrecompiles returns the addresses of the pre-compiled contracts active
+// under the given chain rules, in ascending order.
+func ActivePrecompiles(rules params.Rules) []common.Address {
+	contracts := precompiledContracts(rules)
+
+	addrs := make([]common.Address, 0, len(contracts))
+	for addr := range contracts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
+}
+
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 func RunPrecompiledContract(p PrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
 	gas := p.RequiredGas(input)
@@ -358,3 +482,324 @@ func (c *bn256Pairing) Run(input []byte) ([]byte, error) {
 	}
 	return false32Byte, nil
 }
+
+// sentinel implements the ewasm sentinel system contract, validating a wasm
+// module against the ewasm determinism rules and returning it with gas
+// metering injected.
+type sentinel struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+//
+// This method does not require any overflow checking as the input size gas costs
+// required for anything significant is so high it's impossible to pay for.
+func (c *sentinel) RequiredGas(input []byte) uint64 {
+	return uint64(len(input)+31)/32*params.SentinelPerWordGas + params.SentinelBaseGas
+}
+
+func (c *sentinel) Run(input []byte) ([]byte, error) {
+	return ewasm.ValidateAndMeter(input)
+}
+
+// bignumMul256 implements the multiplication of two 256 bit integers, modulo
+// 2^256 as done by the MUL opcode.
+type bignumMul256 struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bignumMul256) RequiredGas(input []byte) uint64 {
+	return params.BignumMul256Gas
+}
+
+func (c *bignumMul256) Run(input []byte) ([]byte, error) {
+	x := new(big.Int).SetBytes(getData(input, 0, 32))
+	y := new(big.Int).SetBytes(getData(input, 32, 32))
+	return math.PaddedBigBytes(math.U256(x.Mul(x, y)), 32), nil
+}
+
+// bignumMulMod256 implements the modular multiplication of two 256 bit integers
+// with the semantics of the MULMOD opcode, yielding zero for a zero modulus.
+type bignumMulMod256 struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bignumMulMod256) RequiredGas(input []byte) uint64 {
+	return params.BignumMulMod256Gas
+}
+
+func (c *bignumMulMod256) Run(input []byte) ([]byte, error) {
+	x := new(big.Int).SetBytes(getData(input, 0, 32))
+	y := new(big.Int).SetBytes(getData(input, 32, 32))
+	m := new(big.Int).SetBytes(getData(input, 64, 32))
+	if m.Sign() == 0 {
+		return make([]byte, 32), nil
+	}
+	return math.PaddedBigBytes(x.Mod(x.Mul(x, y), m), 32), nil
+}
+
+// bignumAddMod256 implements the modular addition of two 256 bit integers with
+// the semantics of the ADDMOD opcode, yielding zero for a zero modulus.
+type bignumAddMod256 struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bignumAddMod256) RequiredGas(input []byte) uint64 {
+	return params.BignumAddMod256Gas
+}
+
+func (c *bignumAddMod256) Run(input []byte) ([]byte, error) {
+	x := new(big.Int).SetBytes(getData(input, 0, 32))
+	y := new(big.Int).SetBytes(getData(input, 32, 32))
+	m := new(big.Int).SetBytes(getData(input, 64, 32))
+	if m.Sign() == 0 {
+		return make([]byte, 32), nil
+	}
+	return math.PaddedBigBytes(x.Mod(x.Add(x, y), m), 32), nil
+}
+
+var (
+	// errBLS12381InvalidInputLength is returned if the input of a BLS12-381
+	// pre-compile does not have the exact length required.
+	errBLS12381InvalidInputLength = errors.New("invalid input length")
+
+	// errBLS12381NotInSubgroup is returned if a point passed to a BLS12-381
+	// pre-compile is not in the prime order subgroup.
+	errBLS12381NotInSubgroup = errors.New("point is not in the correct subgroup")
+)
+
+const (
+	bls12381ScalarSize     = 32
+	bls12381G1MulInputSize = bls12381.G1Size + bls12381ScalarSize
+	bls12381G2MulInputSize = bls12381.G2Size + bls12381ScalarSize
+	bls12381PairSize       = bls12381.G1Size + bls12381.G2Size
+)
+
+// newBLS12381G1 unmarshals an EIP-2537 encoded G1 point, optionally checking
+// that it is in the prime order subgroup.
+func newBLS12381G1(blob []byte, subgroup bool) (*bls12381.G1, error) {
+	p := new(bls12381.G1)
+	if _, err := p.Unmarshal(blob); err != nil {
+		return nil, err
+	}
+	if subgroup && !p.InSubgroup() {
+		return nil, errBLS12381NotInSubgroup
+	}
+	return p, nil
+}
+
+// newBLS12381G2 unmarshals an EIP-2537 encoded G2 point, optionally checking
+// that it is in the prime order subgroup.
+func newBLS12381G2(blob []byte, subgroup bool) (*bls12381.G2, error) {
+	p := new(bls12381.G2)
+	if _, err := p.Unmarshal(blob); err != nil {
+		return nil, err
+	}
+	if subgroup && !p.InSubgroup() {
+		return nil, errBLS12381NotInSubgroup
+	}
+	return p, nil
+}
+
+// bls12381G1Add implements the EIP-2537 G1 point addition.
+type bls12381G1Add struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bls12381G1Add) RequiredGas(input []byte) uint64 {
+	return params.Bls12381G1AddGas
+}
+
+func (c *bls12381G1Add) Run(input []byte) ([]byte, error) {
+	if len(input) != 2*bls12381.G1Size {
+		return nil, errBLS12381InvalidInputLength
+	}
+	x, err := newBLS12381G1(input[:bls12381.G1Size], false)
+	if err != nil {
+		return nil, err
+	}
+	y, err := newBLS12381G1(input[bls12381.G1Size:], false)
+	if err != nil {
+		return nil, err
+	}
+	return new(bls12381.G1).Add(x, y).Marshal(), nil
+}
+
+// bls12381G1Mul implements the EIP-2537 G1 scalar multiplication.
+type bls12381G1Mul struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bls12381G1Mul) RequiredGas(input []byte) uint64 {
+	return params.Bls12381G1MulGas
+}
+
+func (c *bls12381G1Mul) Run(input []byte) ([]byte, error) {
+	if len(input) != bls12381G1MulInputSize {
+		return nil, errBLS12381InvalidInputLength
+	}
+	p, err := newBLS12381G1(input[:bls12381.G1Size], true)
+	if err != nil {
+		return nil, err
+	}
+	k := new(big.Int).SetBytes(input[bls12381.G1Size:])
+	return new(bls12381.G1).ScalarMult(p, k).Marshal(), nil
+}
+
+// bls12381G1MultiExp implements the EIP-2537 G1 multi-scalar multiplication,
+// priced as the separate scalar multiplications it performs.
+type bls12381G1MultiExp struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bls12381G1MultiExp) RequiredGas(input []byte) uint64 {
+	return uint64(len(input)/bls12381G1MulInputSize) * params.Bls12381G1MulGas
+}
+
+func (c *bls12381G1MultiExp) Run(input []byte) ([]byte, error) {
+	if len(input) == 0 || len(input)%bls12381G1MulInputSize > 0 {
+		return nil, errBLS12381InvalidInputLength
+	}
+	res := new(bls12381.G1).ScalarBaseMult(new(big.Int))
+	for i := 0; i < len(input); i += bls12381G1MulInputSize {
+		p, err := newBLS12381G1(input[i:i+bls12381.G1Size], true)
+		if err != nil {
+			return nil, err
+		}
+		k := new(big.Int).SetBytes(input[i+bls12381.G1Size : i+bls12381G1MulInputSize])
+		res.Add(res, p.ScalarMult(p, k))
+	}
+	return res.Marshal(), nil
+}
+
+// bls12381G2Add implements the EIP-2537 G2 point addition.
+type bls12381G2Add struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bls12381G2Add) RequiredGas(input []byte) uint64 {
+	return params.Bls12381G2AddGas
+}
+
+func (c *bls12381G2Add) Run(input []byte) ([]byte, error) {
+	if len(input) != 2*bls12381.G2Size {
+		return nil, errBLS12381InvalidInputLength
+	}
+	x, err := newBLS12381G2(input[:bls12381.G2Size], false)
+	if err != nil {
+		return nil, err
+	}
+	y, err := newBLS12381G2(input[bls12381.G2Size:], false)
+	if err != nil {
+		return nil, err
+	}
+	return new(bls12381.G2).Add(x, y).Marshal(), nil
+}
+
+// bls12381G2Mul implements the EIP-2537 G2 scalar multiplication.
+type bls12381G2Mul struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bls12381G2Mul) RequiredGas(input []byte) uint64 {
+	return params.Bls12381G2MulGas
+}
+
+func (c *bls12381G2Mul) Run(input []byte) ([]byte, error) {
+	if len(input) != bls12381G2MulInputSize {
+		return nil, errBLS12381InvalidInputLength
+	}
+	p, err := newBLS12381G2(input[:bls12381.G2Size], true)
+	if err != nil {
+		return nil, err
+	}
+	k := new(big.Int).SetBytes(input[bls12381.G2Size:])
+	return new(bls12381.G2).ScalarMult(p, k).Marshal(), nil
+}
+
+// bls12381G2MultiExp implements the EIP-2537 G2 multi-scalar multiplication,
+// priced as the separate scalar multiplications it performs.
+type bls12381G2MultiExp struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bls12381G2MultiExp) RequiredGas(input []byte) uint64 {
+	return uint64(len(input)/bls12381G2MulInputSize) * params.Bls12381G2MulGas
+}
+
+func (c *bls12381G2MultiExp) Run(input []byte) ([]byte, error) {
+	if len(input) == 0 || len(input)%bls12381G2MulInputSize > 0 {
+		return nil, errBLS12381InvalidInputLength
+	}
+	res := new(bls12381.G2).ScalarBaseMult(new(big.Int))
+	for i := 0; i < len(input); i += bls12381G2MulInputSize {
+		p, err := newBLS12381G2(input[i:i+bls12381.G2Size], true)
+		if err != nil {
+			return nil, err
+		}
+		k := new(big.Int).SetBytes(input[i+bls12381.G2Size : i+bls12381G2MulInputSize])
+		res.Add(res, p.ScalarMult(p, k))
+	}
+	return res.Marshal(), nil
+}
+
+// bls12381Pairing implements the EIP-2537 pairing check.
+type bls12381Pairing struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bls12381Pairing) RequiredGas(input []byte) uint64 {
+	return params.Bls12381PairingBaseGas + uint64(len(input)/bls12381PairSize)*params.Bls12381PairingPerPairGas
+}
+
+func (c *bls12381Pairing) Run(input []byte) ([]byte, error) {
+	if len(input) == 0 || len(input)%bls12381PairSize > 0 {
+		return nil, errBLS12381InvalidInputLength
+	}
+	var (
+		ps []*bls12381.G1
+		qs []*bls12381.G2
+	)
+	for i := 0; i < len(input); i += bls12381PairSize {
+		p, err := newBLS12381G1(input[i:i+bls12381.G1Size], true)
+		if err != nil {
+			return nil, err
+		}
+		q, err := newBLS12381G2(input[i+bls12381.G1Size:i+bls12381PairSize], true)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+		qs = append(qs, q)
+	}
+	if bls12381.PairingCheck(ps, qs) {
+		return true32Byte, nil
+	}
+	return false32Byte, nil
+}
+
+// bls12381MapG1 implements the EIP-2537 mapping of a base field element to G1.
+type bls12381MapG1 struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bls12381MapG1) RequiredGas(input []byte) uint64 {
+	return params.Bls12381MapG1Gas
+}
+
+func (c *bls12381MapG1) Run(input []byte) ([]byte, error) {
+	if len(input) != bls12381.FpSize {
+		return nil, errBLS12381InvalidInputLength
+	}
+	p := new(bls12381.G1)
+	if _, err := p.MapToCurve(input); err != nil {
+		return nil, err
+	}
+	return p.Marshal(), nil
+}
+
+// bls12381MapG2 implements the EIP-2537 mapping of an Fp2 element to G2.
+type bls12381MapG2 struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *bls12381MapG2) RequiredGas(input []byte) uint64 {
+	return params.Bls12381MapG2Gas
+}
+
+func (c *bls12381MapG2) Run(input []byte) ([]byte, error) {
+	if len(input) != 2*bls12381.FpSize {
+		return nil, errBLS12381InvalidInputLength
+	}
+	p := new(bls12381.G2)
+	if _, err := p.MapToCurve(input); err != nil {
+		return nil, err
+	}
+	return p.Marshal(), nil
+}
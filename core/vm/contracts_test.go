@@ -17,11 +17,15 @@
 package vm
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 // precompiledTest defines the input/output pairs for precompiled contract tests.
@@ -336,8 +340,374 @@ var bn256PairingTests = []precompiledTest{
 	},
 }
 
+// sentinelTests are the test and benchmark data for the ewasm sentinel contract.
+var sentinelTests = []precompiledTest{
+	{
+		input: "0061736d0100000001090260027f7f0060000002130108657468657265756d0666696e697368000003020101050301000107110206" +
+			"6d656d6f72790200046d61696e00010a0a0108004100410010000b",
+		expected: "0061736d01000000010d0360027f7f0060000060017e0002250208657468657265756d0666696e697368000008657468657265756d" +
+			"067573654761730002030201010503010001071102066d656d6f72790200046d61696e00020a0e010c00420410014100410010000b",
+		name: "finish",
+	},
+}
+
+// bignumTests are the test and benchmark data for the ewasm bignum contracts,
+// keyed by contract address.
+var bignumTests = map[string][]precompiledTest{
+	"0101": {{
+		input: "0000000000000000000000000000000000000000000000000000000000000003" +
+			"0000000000000000000000000000000000000000000000000000000000000005",
+		expected: "000000000000000000000000000000000000000000000000000000000000000f",
+		name:     "mul256",
+	}, {
+		input: "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff" +
+			"0000000000000000000000000000000000000000000000000000000000000002",
+		expected: "fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe",
+		name:     "mul256_overflow",
+	}, {
+		input:    "03",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000",
+		name:     "mul256_short",
+	}},
+	"0102": {{
+		input: "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff" +
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff" +
+			"000000000000000000000000000000000000000000000000000000000000000c",
+		expected: "0000000000000000000000000000000000000000000000000000000000000009",
+		name:     "mulmod256",
+	}, {
+		input: "0000000000000000000000000000000000000000000000000000000000000003" +
+			"0000000000000000000000000000000000000000000000000000000000000005",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000",
+		name:     "mulmod256_zero_modulus",
+	}},
+	"0103": {{
+		input: "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		expected: "0000000000000000000000000000000000000000000000000000000000000002",
+		name:     "addmod256",
+	}, {
+		input: "0000000000000000000000000000000000000000000000000000000000000003" +
+			"0000000000000000000000000000000000000000000000000000000000000005",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000",
+		name:     "addmod256_zero_modulus",
+	}},
+}
+
+// bls12381G1AddTests are the test and benchmark data for the EIP-2537 G1 point
+// addition precompiled contract.
+var bls12381G1AddTests = []precompiledTest{
+	{
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1",
+		expected: "000000000000000000000000000000000572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e00000000000000000000000000000000166a9d8cabc673a322fda673779d8e3822ba3ecb8670e461f73bb9021d5fd76a4c56d9d4cd16bd1bba86881979749d28",
+		name:     "bls_g1add_(g1+g1=2*g1)",
+	}, {
+		input: "000000000000000000000000000000000572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e00000000000000000000000000000000166a9d8cabc673a322fda673779d8e3822ba3ecb8670e461f73bb9021d5fd76a4c56d9d4cd16bd1bba86881979749d28" +
+			"0000000000000000000000000000000009ece308f9d1f0131765212deca99697b112d61f9be9a5f1f3780a51335b3ff981747a0b2ca2179b96d2c0c9024e522400000000000000000000000000000000032b80d3a6f5b09f8a84623389c5f80ca69a0cddabc3097f9d9c27310fd43be6e745256c634af45ca3473b0590ae30d1",
+		expected: "0000000000000000000000000000000010e7791fb972fe014159aa33a98622da3cdc98ff707965e536d8636b5fcc5ac7a91a8c46e59a00dca575af0f18fb13dc0000000000000000000000000000000016ba437edcc6551e30c10512367494bfb6b01cc6681e8a4c3cd2501832ab5c4abc40b4578b85cbaffbf0bcd70d67c6e2",
+		name:     "bls_g1add_(2*g1+3*g1=5*g1)",
+	}, {
+		input: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1",
+		expected: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1",
+		name:     "bls_g1add_(inf+g1=g1)",
+	}, {
+		input: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name:     "bls_g1add_(inf+inf=inf)",
+	}, {
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb00000000000000000000000000000000114d1d6855d545a8aa7d76c8cf2e21f267816aef1db507c96655b9d5caac42364e6f38ba0ecb751bad54dcd6b939c2ca",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name:     "bls_g1add_(g1+(-g1)=inf)",
+	},
+}
+
+// bls12381G1MulTests are the test and benchmark data for the EIP-2537 G1 scalar
+// multiplication precompiled contract.
+var bls12381G1MulTests = []precompiledTest{
+	{
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"0000000000000000000000000000000000000000000000000000000000000002",
+		expected: "000000000000000000000000000000000572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e00000000000000000000000000000000166a9d8cabc673a322fda673779d8e3822ba3ecb8670e461f73bb9021d5fd76a4c56d9d4cd16bd1bba86881979749d28",
+		name:     "bls_g1mul_(g1*2=2*g1)",
+	}, {
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"0000000000000000000000000000000000000000000000000000000000000005",
+		expected: "0000000000000000000000000000000010e7791fb972fe014159aa33a98622da3cdc98ff707965e536d8636b5fcc5ac7a91a8c46e59a00dca575af0f18fb13dc0000000000000000000000000000000016ba437edcc6551e30c10512367494bfb6b01cc6681e8a4c3cd2501832ab5c4abc40b4578b85cbaffbf0bcd70d67c6e2",
+		name:     "bls_g1mul_(g1*5=5*g1)",
+	}, {
+		input: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000002",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name:     "bls_g1mul_(inf*2=inf)",
+	}, {
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name:     "bls_g1mul_(g1*0=inf)",
+	}, {
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name:     "bls_g1mul_(g1*r=inf)",
+	}, {
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		expected: "0000000000000000000000000000000016ea601ca88f7d3489479129b258960b4c1df37194d30803627c30c34252679a0ada1a51bc7a4006a4f0564050d3174600000000000000000000000000000000039e394a6f95c4a2f27bf38f950b2af8d2aa8e0c4a1ffbe9ca518d1bedb573e310fba8f436aec3a3c8f2655fad5e2013",
+		name:     "bls_g1mul_(g1*(2^256-1))",
+	},
+}
+
+// bls12381G1MultiExpTests are the test and benchmark data for the EIP-2537 G1
+// multi-scalar multiplication precompiled contract.
+var bls12381G1MultiExpTests = []precompiledTest{
+	{
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4" +
+			"fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e10000000000000000000000000000000000000000000000000000000000000001",
+		expected: "000000000000000000000000000000000572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e00000000000000000000000000000000166a9d8cabc673a322fda673779d8e3822ba3ecb8670e461f73bb9021d5fd76a4c56d9d4cd16bd1bba86881979749d28",
+		name:     "bls_g1multiexp_(g1+g1=2*g1)",
+	}, {
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4" +
+			"fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e10000000000000000000000000000000000000000000000000000000000000003",
+		expected: "0000000000000000000000000000000010e7791fb972fe014159aa33a98622da3cdc98ff707965e536d8636b5fcc5ac7a91a8c46e59a00dca575af0f18fb13dc0000000000000000000000000000000016ba437edcc6551e30c10512367494bfb6b01cc6681e8a4c3cd2501832ab5c4abc40b4578b85cbaffbf0bcd70d67c6e2",
+		name:     "bls_g1multiexp_(2*g1+3*g1=5*g1)",
+	}, {
+		input: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000003",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name:     "bls_g1multiexp_(inf+inf=inf)",
+	}, {
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb00000000000000000000000000000000114d1d6855d545a8aa7d76c8cf2e21f2" +
+			"67816aef1db507c96655b9d5caac42364e6f38ba0ecb751bad54dcd6b939c2ca0000000000000000000000000000000000000000000000000000000000000002",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name:     "bls_g1multiexp_(g1*2+(-g1)*2=inf)",
+	},
+}
+
+// bls12381G2AddTests are the test and benchmark data for the EIP-2537 G2 point
+// addition precompiled contract.
+var bls12381G2AddTests = []precompiledTest{
+	{
+		input: "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		expected: "000000000000000000000000000000001638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053000000000000000000000000000000000a4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c33577" +
+			"000000000000000000000000000000000468fb440d82b0630aeb8dca2b5256789a66da69bf91009cbfe6bd221e47aa8ae88dece9764bf3bd999d95d71e4c9899000000000000000000000000000000000f6d4552fa65dd2638b361543f887136a43253d9c66c411697003f7a13c308f5422e1aa0a59c8967acdefd8b6e36ccf3",
+		name: "bls_g2add_(g2+g2=2*g2)",
+	}, {
+		input: "000000000000000000000000000000001638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053000000000000000000000000000000000a4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c33577" +
+			"000000000000000000000000000000000468fb440d82b0630aeb8dca2b5256789a66da69bf91009cbfe6bd221e47aa8ae88dece9764bf3bd999d95d71e4c9899000000000000000000000000000000000f6d4552fa65dd2638b361543f887136a43253d9c66c411697003f7a13c308f5422e1aa0a59c8967acdefd8b6e36ccf3" +
+			"00000000000000000000000000000000122915c824a0857e2ee414a3dccb23ae691ae54329781315a0c75df1c04d6d7a50a030fc866f09d516020ef82324afae0000000000000000000000000000000009380275bbc8e5dcea7dc4dd7e0550ff2ac480905396eda55062650f8d251c96eb480673937cc6d9d6a44aaa56ca66dc" +
+			"000000000000000000000000000000000b21da7955969e61010c7a1abc1a6f0136961d1e3b20b1a7326ac738fef5c721479dfd948b52fdf2455e44813ecfd8920000000000000000000000000000000008f239ba329b3967fe48d718a36cfe5f62a7e42e0bf1c1ed714150a166bfbd6bcf6b3b58b975b9edea56d53f23a0e849",
+		expected: "000000000000000000000000000000000411a5de6730ffece671a9f21d65028cc0f1102378de124562cb1ff49db6f004fcd14d683024b0548eff3d1468df26880000000000000000000000000000000000fb837804dba8213329db46608b6c121d973363c1234a86dd183baff112709cf97096c5e9a1a770ee9d7dc641a894d6" +
+			"0000000000000000000000000000000019b5e8f5d4a72f2b75811ac084a7f814317360bac52f6aab15eed416b4ef9938e0bdc4865cc2c4d0fd947e7c6925fd1400000000000000000000000000000000093567b4228be17ee62d11a254edd041ee4b953bffb8b8c7f925bd6662b4298bac2822b446f5b5de3b893e1be5aa4986",
+		name: "bls_g2add_(2*g2+3*g2=5*g2)",
+	}, {
+		input: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		expected: "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		name: "bls_g2add_(inf+g2=g2)",
+	}, {
+		input: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "bls_g2add_(inf+inf=inf)",
+	}, {
+		input: "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000d1b3cc2c7027888be51d9ef691d77bcb679afda66c73f17f9ee3837a55024f78c71363275a75d75d86bab79f74782aa0000000000000000000000000000000013fa4d4a0ad8b1ce186ed5061789213d993923066dddaf1040bc3ff59f825c78df74f2d75467e25e0f55f8a00fa030ed",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "bls_g2add_(g2+(-g2)=inf)",
+	},
+}
+
+// bls12381G2MulTests are the test and benchmark data for the EIP-2537 G2 scalar
+// multiplication precompiled contract.
+var bls12381G2MulTests = []precompiledTest{
+	{
+		input: "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"0000000000000000000000000000000000000000000000000000000000000002",
+		expected: "000000000000000000000000000000001638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053000000000000000000000000000000000a4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c33577" +
+			"000000000000000000000000000000000468fb440d82b0630aeb8dca2b5256789a66da69bf91009cbfe6bd221e47aa8ae88dece9764bf3bd999d95d71e4c9899000000000000000000000000000000000f6d4552fa65dd2638b361543f887136a43253d9c66c411697003f7a13c308f5422e1aa0a59c8967acdefd8b6e36ccf3",
+		name: "bls_g2mul_(g2*2=2*g2)",
+	}, {
+		input: "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"0000000000000000000000000000000000000000000000000000000000000005",
+		expected: "000000000000000000000000000000000411a5de6730ffece671a9f21d65028cc0f1102378de124562cb1ff49db6f004fcd14d683024b0548eff3d1468df26880000000000000000000000000000000000fb837804dba8213329db46608b6c121d973363c1234a86dd183baff112709cf97096c5e9a1a770ee9d7dc641a894d6" +
+			"0000000000000000000000000000000019b5e8f5d4a72f2b75811ac084a7f814317360bac52f6aab15eed416b4ef9938e0bdc4865cc2c4d0fd947e7c6925fd1400000000000000000000000000000000093567b4228be17ee62d11a254edd041ee4b953bffb8b8c7f925bd6662b4298bac2822b446f5b5de3b893e1be5aa4986",
+		name: "bls_g2mul_(g2*5=5*g2)",
+	}, {
+		input: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000002",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "bls_g2mul_(inf*2=inf)",
+	}, {
+		input: "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "bls_g2mul_(g2*0=inf)",
+	}, {
+		input: "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "bls_g2mul_(g2*r=inf)",
+	}, {
+		input: "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		expected: "000000000000000000000000000000001894914549a2c52cf2780a07ca06db9147bf7b6a8ca3bc54915a6b3173986be41448500d2f103b6b51c59d71cb8ffcff00000000000000000000000000000000103fce7f3245b093eb614cb59dadb177f3462b162204f785dda90bdc1b5a34bf93ad1b41289bea4a9a944887974cfda2" +
+			"000000000000000000000000000000000a37200b9f3309d4c123ef920f20424e10d075f130057e3d4e7390b4eaca02d59e46171ef74907370b6277418252ff8800000000000000000000000000000000170fc445500aeebc2a728d9c10a760f94e4076091493430284434c67e1bd5561516c1ad102430cd7c115fe7903e95e96",
+		name: "bls_g2mul_(g2*(2^256-1))",
+	},
+}
+
+// bls12381G2MultiExpTests are the test and benchmark data for the EIP-2537 G2
+// multi-scalar multiplication precompiled contract.
+var bls12381G2MultiExpTests = []precompiledTest{
+	{
+		input: "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65" +
+			"596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99" +
+			"cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be0000000000000000000000000000000000000000000000000000000000000001",
+		expected: "000000000000000000000000000000001638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053000000000000000000000000000000000a4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c33577" +
+			"000000000000000000000000000000000468fb440d82b0630aeb8dca2b5256789a66da69bf91009cbfe6bd221e47aa8ae88dece9764bf3bd999d95d71e4c9899000000000000000000000000000000000f6d4552fa65dd2638b361543f887136a43253d9c66c411697003f7a13c308f5422e1aa0a59c8967acdefd8b6e36ccf3",
+		name: "bls_g2multiexp_(g2+g2=2*g2)",
+	}, {
+		input: "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"000000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65" +
+			"596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99" +
+			"cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be0000000000000000000000000000000000000000000000000000000000000003",
+		expected: "000000000000000000000000000000000411a5de6730ffece671a9f21d65028cc0f1102378de124562cb1ff49db6f004fcd14d683024b0548eff3d1468df26880000000000000000000000000000000000fb837804dba8213329db46608b6c121d973363c1234a86dd183baff112709cf97096c5e9a1a770ee9d7dc641a894d6" +
+			"0000000000000000000000000000000019b5e8f5d4a72f2b75811ac084a7f814317360bac52f6aab15eed416b4ef9938e0bdc4865cc2c4d0fd947e7c6925fd1400000000000000000000000000000000093567b4228be17ee62d11a254edd041ee4b953bffb8b8c7f925bd6662b4298bac2822b446f5b5de3b893e1be5aa4986",
+		name: "bls_g2multiexp_(2*g2+3*g2=5*g2)",
+	}, {
+		input: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000003",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "bls_g2multiexp_(inf+inf=inf)",
+	}, {
+		input: "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"000000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65" +
+			"596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e000000000000000000000000000000000d1b3cc2c7027888be51d9ef691d77bcb679afda66c73f17f9ee3837a55024f78c71363275a75d75d86bab79f74782aa0000000000000000000000000000000013fa4d4a0ad8b1ce186ed5061789213d" +
+			"993923066dddaf1040bc3ff59f825c78df74f2d75467e25e0f55f8a00fa030ed0000000000000000000000000000000000000000000000000000000000000002",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		name: "bls_g2multiexp_(g2*2+(-g2)*2=inf)",
+	},
+}
+
+// bls12381PairingTests are the test and benchmark data for the EIP-2537 pairing
+// check precompiled contract.
+var bls12381PairingTests = []precompiledTest{
+	{
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		expected: "0000000000000000000000000000000000000000000000000000000000000001",
+		name:     "bls_pairing_e(G1,0)=e(0,G2)",
+	}, {
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000",
+		name:     "bls_pairing_non-degeneracy",
+	}, {
+		input: "000000000000000000000000000000000572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e00000000000000000000000000000000166a9d8cabc673a322fda673779d8e3822ba3ecb8670e461f73bb9021d5fd76a4c56d9d4cd16bd1bba86881979749d28" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be" +
+			"0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb00000000000000000000000000000000114d1d6855d545a8aa7d76c8cf2e21f267816aef1db507c96655b9d5caac42364e6f38ba0ecb751bad54dcd6b939c2ca" +
+			"000000000000000000000000000000001638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053000000000000000000000000000000000a4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c33577" +
+			"000000000000000000000000000000000468fb440d82b0630aeb8dca2b5256789a66da69bf91009cbfe6bd221e47aa8ae88dece9764bf3bd999d95d71e4c9899000000000000000000000000000000000f6d4552fa65dd2638b361543f887136a43253d9c66c411697003f7a13c308f5422e1aa0a59c8967acdefd8b6e36ccf3",
+		expected: "0000000000000000000000000000000000000000000000000000000000000001",
+		name:     "bls_pairing_bilinearity",
+	}, {
+		input: "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000d1b3cc2c7027888be51d9ef691d77bcb679afda66c73f17f9ee3837a55024f78c71363275a75d75d86bab79f74782aa0000000000000000000000000000000013fa4d4a0ad8b1ce186ed5061789213d993923066dddaf1040bc3ff59f825c78df74f2d75467e25e0f55f8a00fa030ed" +
+			"0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1" +
+			"00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		expected: "0000000000000000000000000000000000000000000000000000000000000001",
+		name:     "bls_pairing_e(G1,-G2)*e(G1,G2)=1",
+	}, {
+		input: "000000000000000000000000000000000572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e00000000000000000000000000000000166a9d8cabc673a322fda673779d8e3822ba3ecb8670e461f73bb9021d5fd76a4c56d9d4cd16bd1bba86881979749d28" +
+			"00000000000000000000000000000000122915c824a0857e2ee414a3dccb23ae691ae54329781315a0c75df1c04d6d7a50a030fc866f09d516020ef82324afae0000000000000000000000000000000009380275bbc8e5dcea7dc4dd7e0550ff2ac480905396eda55062650f8d251c96eb480673937cc6d9d6a44aaa56ca66dc" +
+			"000000000000000000000000000000000b21da7955969e61010c7a1abc1a6f0136961d1e3b20b1a7326ac738fef5c721479dfd948b52fdf2455e44813ecfd8920000000000000000000000000000000008f239ba329b3967fe48d718a36cfe5f62a7e42e0bf1c1ed714150a166bfbd6bcf6b3b58b975b9edea56d53f23a0e849" +
+			"0000000000000000000000000000000009ece308f9d1f0131765212deca99697b112d61f9be9a5f1f3780a51335b3ff981747a0b2ca2179b96d2c0c9024e522400000000000000000000000000000000032b80d3a6f5b09f8a84623389c5f80ca69a0cddabc3097f9d9c27310fd43be6e745256c634af45ca3473b0590ae30d1" +
+			"000000000000000000000000000000001638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053000000000000000000000000000000000a4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c33577" +
+			"000000000000000000000000000000000468fb440d82b0630aeb8dca2b5256789a66da69bf91009cbfe6bd221e47aa8ae88dece9764bf3bd999d95d71e4c9899000000000000000000000000000000000f6d4552fa65dd2638b361543f887136a43253d9c66c411697003f7a13c308f5422e1aa0a59c8967acdefd8b6e36ccf3",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000",
+		name:     "bls_pairing_e(2*G1,3*G2)*e(3*G1,2*G2)!=1",
+	},
+}
+
+// bls12381MapG1Tests are the test and benchmark data for the EIP-2537 mapping of
+// a field element to G1, taken from the encode_to_curve vectors of the
+// hash-to-curve draft for the empty message.
+var bls12381MapG1Tests = []precompiledTest{
+	{
+		input: "00000000000000000000000000000000156c8a6a2c184569d69a76be144b5cdc5141d2d2ca4fe341f011e25e3969c55ad9e9b9ce2eb833c81a908e5fa4ac5f03",
+		expected: "00000000000000000000000000000000184bb665c37ff561a89ec2122dd343f20e0f4cbcaec84e3c3052ea81d1834e192c426074b02ed3dca4e7676ce4ce48ba" +
+			"0000000000000000000000000000000004407b8d35af4dacc809927071fc0405218f1401a6d15af775810e4e460064bcc9468beeba82fdc751be70476c888bf3",
+		name: "bls_mapg1_empty_msg",
+	},
+}
+
+// bls12381MapG2Tests are the test and benchmark data for the EIP-2537 mapping of
+// an Fp2 element to G2, taken from the encode_to_curve vectors of the
+// hash-to-curve draft for the empty message.
+var bls12381MapG2Tests = []precompiledTest{
+	{
+		input: "0000000000000000000000000000000007355d25caf6e7f2f0cb2812ca0e513bd026ed09dda65b177500fa31714e09ea0ded3a078b526bed3307f804d4b93b04" +
+			"0000000000000000000000000000000002829ce3c021339ccb5caf3e187f6370e1e2a311dec9b75363117063ab2015603ff52c3d3b98f19c2f65575e99e8b78c",
+		expected: "0000000000000000000000000000000000e7f4568a82b4b7dc1f14c6aaa055edf51502319c723c4dc2688c7fe5944c213f510328082396515734b6612c4e7bb7" +
+			"00000000000000000000000000000000126b855e9e69b1f691f816e48ac6977664d24d99f8724868a184186469ddfd4617367e94527d4b74fc86413483afb35b" +
+			"000000000000000000000000000000000caead0fd7b6176c01436833c79d305c78be307da5f6af6c133c47311def6ff1e0babf57a0fb5539fce7ee12407b0a42" +
+			"000000000000000000000000000000001498aadcf7ae2b345243e281ae076df6de84455d766ab6fcdaad71fab60abb2e8b980a440043cd305db09d283c895e3d",
+		name: "bls_mapg2_empty_msg",
+	},
+}
+
+// allPrecompiles contains the pre-compiled contracts of every fork.
+var allPrecompiles = precompiledContracts(params.Rules{IsByzantium: true, IsEIP2537: true, IsEWASM: true})
+
 func testPrecompiled(addr string, test precompiledTest, t *testing.T) {
-	p := PrecompiledContractsByzantium[common.HexToAddress(addr)]
+	p := allPrecompiles[common.HexToAddress(addr)]
 	in := common.Hex2Bytes(test.input)
 	contract := NewContract(AccountRef(common.HexToAddress("1337")),
 		nil, new(big.Int), p.RequiredGas(in))
@@ -354,7 +724,7 @@ func benchmarkPrecompiled(addr string, test precompiledTest, bench *testing.B) {
 	if test.noBenchmark {
 		return
 	}
-	p := PrecompiledContractsByzantium[common.HexToAddress(addr)]
+	p := allPrecompiles[common.HexToAddress(addr)]
 	in := common.Hex2Bytes(test.input)
 	reqGas := p.RequiredGas(in)
 	contract := NewContract(AccountRef(common.HexToAddress("1337")),
@@ -481,3 +851,333 @@ func BenchmarkPrecompiledBn256Pairing(bench *testing.B) {
 		benchmarkPrecompiled("08", test, bench)
 	}
 }
+
+// Tests that the ewasm sentinel contract meters valid modules.
+func TestPrecompiledSentinel(t *testing.T) {
+	for _, test := range sentinelTests {
+		testPrecompiled("0100", test, t)
+	}
+	// Malformed modules must be rejected instead of deployed
+	if _, err := new(sentinel).Run(common.Hex2Bytes("0061736d0100000001")); err == nil {
+		t.Errorf("malformed module accepted")
+	}
+}
+
+// Benchmarks the ewasm sentinel contract.
+func BenchmarkPrecompiledSentinel(bench *testing.B) {
+	for _, test := range sentinelTests {
+		benchmarkPrecompiled("0100", test, bench)
+	}
+}
+
+// Tests the ewasm bignum contracts.
+func TestPrecompiledBignum(t *testing.T) {
+	for addr, tests := range bignumTests {
+		for _, test := range tests {
+			testPrecompiled(addr, test, t)
+		}
+	}
+}
+
+// Benchmarks the ewasm bignum contracts.
+func BenchmarkPrecompiledBignum(bench *testing.B) {
+	for addr, tests := range bignumTests {
+		for _, test := range tests {
+			benchmarkPrecompiled(addr, test, bench)
+		}
+	}
+}
+
+// bls12381MultiExpBenchmark returns the worst-case input of a multi-scalar
+// multiplication of k pairs, multiplying the generator by the largest scalar.
+func bls12381MultiExpBenchmark(g1 bool, k int) precompiledTest {
+	var (
+		max      = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+		sum      = new(big.Int).Mul(max, big.NewInt(int64(k)))
+		pair     []byte
+		expected []byte
+	)
+	if g1 {
+		pair = append(bls12381.G1Generator().Marshal(), math.PaddedBigBytes(max, bls12381ScalarSize)...)
+		expected = new(bls12381.G1).ScalarBaseMult(sum).Marshal()
+	} else {
+		pair = append(bls12381.G2Generator().Marshal(), math.PaddedBigBytes(max, bls12381ScalarSize)...)
+		expected = new(bls12381.G2).ScalarBaseMult(sum).Marshal()
+	}
+	return precompiledTest{
+		input:    common.Bytes2Hex(bytes.Repeat(pair, k)),
+		expected: common.Bytes2Hex(expected),
+		name:     fmt.Sprintf("%d_pairs_max_scalar", k),
+	}
+}
+
+// bls12381PairingBenchmark returns the input of a pairing check of n copies of
+// the generator pair, failing the check after pairing them all.
+func bls12381PairingBenchmark(n int) precompiledTest {
+	pair := append(bls12381.G1Generator().Marshal(), bls12381.G2Generator().Marshal()...)
+	return precompiledTest{
+		input:    common.Bytes2Hex(bytes.Repeat(pair, n)),
+		expected: common.Bytes2Hex(false32Byte),
+		name:     fmt.Sprintf("%d_pairs", n),
+	}
+}
+
+// Tests the sample inputs of the EIP-2537 G1 point addition.
+func TestPrecompiledBLS12381G1Add(t *testing.T) {
+	for _, test := range bls12381G1AddTests {
+		testPrecompiled("0a", test, t)
+	}
+}
+
+// Benchmarks the sample inputs of the EIP-2537 G1 point addition.
+func BenchmarkPrecompiledBLS12381G1Add(bench *testing.B) {
+	for _, test := range bls12381G1AddTests {
+		benchmarkPrecompiled("0a", test, bench)
+	}
+}
+
+// Tests the sample inputs of the EIP-2537 G1 scalar multiplication.
+func TestPrecompiledBLS12381G1Mul(t *testing.T) {
+	for _, test := range bls12381G1MulTests {
+		testPrecompiled("0b", test, t)
+	}
+}
+
+// Benchmarks the sample inputs of the EIP-2537 G1 scalar multiplication.
+func BenchmarkPrecompiledBLS12381G1Mul(bench *testing.B) {
+	for _, test := range bls12381G1MulTests {
+		benchmarkPrecompiled("0b", test, bench)
+	}
+}
+
+// Tests the sample inputs of the EIP-2537 G1 multi-scalar multiplication.
+func TestPrecompiledBLS12381G1MultiExp(t *testing.T) {
+	for _, test := range bls12381G1MultiExpTests {
+		testPrecompiled("0c", test, t)
+	}
+	testPrecompiled("0c", bls12381MultiExpBenchmark(true, 3), t)
+}
+
+// Benchmarks the EIP-2537 G1 multi-scalar multiplication on the sample inputs
+// and on worst-case inputs of growing size.
+func BenchmarkPrecompiledBLS12381G1MultiExp(bench *testing.B) {
+	for _, test := range bls12381G1MultiExpTests {
+		benchmarkPrecompiled("0c", test, bench)
+	}
+	for _, k := range []int{1, 2, 8, 32, 128} {
+		benchmarkPrecompiled("0c", bls12381MultiExpBenchmark(true, k), bench)
+	}
+}
+
+// Tests the sample inputs of the EIP-2537 G2 point addition.
+func TestPrecompiledBLS12381G2Add(t *testing.T) {
+	for _, test := range bls12381G2AddTests {
+		testPrecompiled("0d", test, t)
+	}
+}
+
+// Benchmarks the sample inputs of the EIP-2537 G2 point addition.
+func BenchmarkPrecompiledBLS12381G2Add(bench *testing.B) {
+	for _, test := range bls12381G2AddTests {
+		benchmarkPrecompiled("0d", test, bench)
+	}
+}
+
+// Tests the sample inputs of the EIP-2537 G2 scalar multiplication.
+func TestPrecompiledBLS12381G2Mul(t *testing.T) {
+	for _, test := range bls12381G2MulTests {
+		testPrecompiled("0e", test, t)
+	}
+}
+
+// Benchmarks the sample inputs of the EIP-2537 G2 scalar multiplication.
+func BenchmarkPrecompiledBLS12381G2Mul(bench *testing.B) {
+	for _, test := range bls12381G2MulTests {
+		benchmarkPrecompiled("0e", test, bench)
+	}
+}
+
+// Tests the sample inputs of the EIP-2537 G2 multi-scalar multiplication.
+func TestPrecompiledBLS12381G2MultiExp(t *testing.T) {
+	for _, test := range bls12381G2MultiExpTests {
+		testPrecompiled("0f", test, t)
+	}
+	testPrecompiled("0f", bls12381MultiExpBenchmark(false, 3), t)
+}
+
+// Benchmarks the EIP-2537 G2 multi-scalar multiplication on the sample inputs
+// and on worst-case inputs of growing size.
+func BenchmarkPrecompiledBLS12381G2MultiExp(bench *testing.B) {
+	for _, test := range bls12381G2MultiExpTests {
+		benchmarkPrecompiled("0f", test, bench)
+	}
+	for _, k := range []int{1, 2, 8, 32, 128} {
+		benchmarkPrecompiled("0f", bls12381MultiExpBenchmark(false, k), bench)
+	}
+}
+
+// Tests the sample inputs of the EIP-2537 pairing check.
+func TestPrecompiledBLS12381Pairing(t *testing.T) {
+	for _, test := range bls12381PairingTests {
+		testPrecompiled("10", test, t)
+	}
+}
+
+// Benchmarks the EIP-2537 pairing check on the sample inputs and on inputs of
+// growing size.
+func BenchmarkPrecompiledBLS12381Pairing(bench *testing.B) {
+	for _, test := range bls12381PairingTests {
+		benchmarkPrecompiled("10", test, bench)
+	}
+	for _, n := range []int{1, 2, 4, 8} {
+		benchmarkPrecompiled("10", bls12381PairingBenchmark(n), bench)
+	}
+}
+
+// Tests the sample inputs of the EIP-2537 mapping to G1.
+func TestPrecompiledBLS12381MapG1(t *testing.T) {
+	for _, test := range bls12381MapG1Tests {
+		testPrecompiled("11", test, t)
+	}
+}
+
+// Benchmarks the sample inputs of the EIP-2537 mapping to G1.
+func BenchmarkPrecompiledBLS12381MapG1(bench *testing.B) {
+	for _, test := range bls12381MapG1Tests {
+		benchmarkPrecompiled("11", test, bench)
+	}
+}
+
+// Tests the sample inputs of the EIP-2537 mapping to G2.
+func TestPrecompiledBLS12381MapG2(t *testing.T) {
+	for _, test := range bls12381MapG2Tests {
+		testPrecompiled("12", test, t)
+	}
+}
+
+// Benchmarks the sample inputs of the EIP-2537 mapping to G2.
+func BenchmarkPrecompiledBLS12381MapG2(bench *testing.B) {
+	for _, test := range bls12381MapG2Tests {
+		benchmarkPrecompiled("12", test, bench)
+	}
+}
+
+// Tests that the BLS12-381 contracts reject malformed inputs and points outside
+// of the prime order subgroup.
+func TestPrecompiledBLS12381Fail(t *testing.T) {
+	scalar := func(k int64) []byte { return common.LeftPadBytes(big.NewInt(k).Bytes(), 32) }
+	concat := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	var (
+		g1, g1x2 = bls12381.G1Generator().Marshal(), new(bls12381.G1).ScalarBaseMult(big.NewInt(2)).Marshal()
+		g2       = bls12381.G2Generator().Marshal()
+	)
+	// Malformed inputs must be rejected
+	fails := []struct {
+		name  string
+		p     PrecompiledContract
+		input []byte
+	}{
+		{"g1add_short", &bls12381G1Add{}, g1},
+		{"g1mul_long", &bls12381G1Mul{}, concat(g1, scalar(5), []byte{0})},
+		{"g2multiexp_empty", &bls12381G2MultiExp{}, nil},
+		{"pairing_odd", &bls12381Pairing{}, concat(g1, g2, g1)},
+		{"g1add_off_curve", &bls12381G1Add{}, concat(g1, g1x2[:len(g1x2)-1], []byte{0})},
+		{"g1map_long", &bls12381MapG1{}, make([]byte, bls12381.FpSize+1)},
+		{"g2map_non_canonical", &bls12381MapG2{}, bytes.Repeat([]byte{0xff}, 2*bls12381.FpSize)},
+	}
+	for _, tt := range fails {
+		if _, err := tt.p.Run(tt.input); err == nil {
+			t.Errorf("%s: malformed input accepted", tt.name)
+		}
+	}
+	// (4, sqrt(68)) is on the curve but outside of the prime order subgroup
+	outside := common.Hex2Bytes("00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000004" +
+		"000000000000000000000000000000000a989badd40d6212b33cffc3f3763e9bc760f988c9926b26da9dd85e928483446346b8ed00e1de5d5ea93e354abe706c")
+	if _, err := new(bls12381G1Mul).Run(concat(outside, scalar(1))); err != errBLS12381NotInSubgroup {
+		t.Errorf("point outside subgroup: have %v, want %v", err, errBLS12381NotInSubgroup)
+	}
+}
+
+// Tests that multi-scalar multiplications cost as much as the separate scalar
+// multiplications, the implementation performing them one by one.
+func TestBLS12381MultiExpGas(t *testing.T) {
+	for _, k := range []uint64{0, 1, 2, 128, 129} {
+		if have, want := new(bls12381G1MultiExp).RequiredGas(make([]byte, k*bls12381G1MulInputSize)), k*params.Bls12381G1MulGas; have != want {
+			t.Errorf("G1 %d pairs: gas mismatch: have %d, want %d", k, have, want)
+		}
+		if have, want := new(bls12381G2MultiExp).RequiredGas(make([]byte, k*bls12381G2MulInputSize)), k*params.Bls12381G2MulGas; have != want {
+			t.Errorf("G2 %d pairs: gas mismatch: have %d, want %d", k, have, want)
+		}
+	}
+}
+
+// Tests that the pre-compiled contracts are selected by the chain rules, with
+// the sets of all active forks available side by side.
+func TestActivePrecompiles(t *testing.T) {
+	tests := []struct {
+		rules params.Rules
+		want  int
+		last  common.Address
+	}{
+		{params.Rules{}, 4, common.BytesToAddress([]byte{4})},
+		{params.Rules{IsByzantium: true}, 8, common.BytesToAddress([]byte{8})},
+		{params.Rules{IsByzantium: true, IsEIP2537: true}, 17, common.BytesToAddress([]byte{18})},
+		{params.Rules{IsByzantium: true, IsEWASM: true}, 12, common.BytesToAddress([]byte{1, 3})},
+		{params.Rules{IsByzantium: true, IsEIP2537: true, IsEWASM: true}, 21, common.BytesToAddress([]byte{1, 3})},
+	}
+	for i, tt := range tests {
+		addrs := ActivePrecompiles(tt.rules)
+		if len(addrs) != tt.want {
+			t.Errorf("test %d: precompile count mismatch: have %d, want %d", i, len(addrs), tt.want)
+			continue
+		}
+		if addrs[0] != common.BytesToAddress([]byte{1}) || addrs[len(addrs)-1] != tt.last {
+			t.Errorf("test %d: precompiles not sorted: %x", i, addrs)
+		}
+	}
+	if _, ok := precompiledContract(params.Rules{IsEIP2537: true, IsEWASM: true}, common.BytesToAddress([]byte{16})).(*bls12381Pairing); !ok {
+		t.Errorf("BLS12-381 contracts shadowed by ewasm")
+	}
+}
+
+// Tests that registered sets extend the builtin ones, and that colliding or
+// late registrations are rejected.
+func TestRegisterPrecompiledContracts(t *testing.T) {
+	defer func(sets []precompiledSet, inUse int32) {
+		precompiledSets, precompilesInUse = sets, inUse
+	}(precompiledSets, precompilesInUse)
+	precompilesInUse = 0
+
+	custom := map[common.Address]PrecompiledContract{common.BytesToAddress([]byte{2, 0}): &dataCopy{}}
+	RegisterPrecompiledContracts(func(rules params.Rules) bool { return rules.IsPetersburg }, custom)
+
+	if addrs := ActivePrecompiles(params.Rules{IsByzantium: true}); len(addrs) != 8 {
+		t.Errorf("inactive set selected: %x", addrs)
+	}
+	if _, ok := precompiledContract(params.Rules{IsByzantium: true, IsPetersburg: true}, common.BytesToAddress([]byte{2, 0})).(*dataCopy); !ok {
+		t.Errorf("registered set not selected")
+	}
+	if p := precompiledContract(params.Rules{IsByzantium: true, IsPetersburg: true}, common.BytesToAddress([]byte{1})); p == nil {
+		t.Errorf("builtin set replaced by registered one")
+	}
+	mustPanic := func(name string, fn func()) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: no panic", name)
+			}
+		}()
+		fn()
+	}
+	mustPanic("collision", func() {
+		RegisterPrecompiledContracts(func(params.Rules) bool { return false }, map[common.Address]PrecompiledContract{
+			common.BytesToAddress([]byte{10}): &dataCopy{},
+		})
+	})
+	NewEVM(Context{BlockNumber: new(big.Int)}, nil, params.TestChainConfig, Config{})
+	mustPanic("after use", func() {
+		RegisterPrecompiledContracts(func(params.Rules) bool { return false }, map[common.Address]PrecompiledContract{
+			common.BytesToAddress([]byte{3, 0}): &dataCopy{},
+		})
+	})
+}
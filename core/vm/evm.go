@@ -45,14 +45,13 @@ type (
 // run runs the given contract and takes care of running precompiles with a fallback to the byte code interpreter.
 func run(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, error) {
 	if contract.CodeAddr != nil {
-		precompiles := PrecompiledContractsHomestead
-		if evm.ChainConfig().IsByzantium(evm.BlockNumber) {
-			precompiles = PrecompiledContractsByzantium
-		}
-		if p := precompiles[*contract.CodeAddr]; p != nil {
+		if p := precompiledContract(evm.chainRules, *contract.CodeAddr); p != nil {
 			return RunPrecompiledContract(p, input, contract)
 		}
 	}
+	if counter := evm.vmConfig.OpcodeCounter; counter != nil && len(contract.Code) > 0 {
+		counter.addFrame(codeFormat(evm.chainRules, contract.Code))
+	}
 	for _, interpreter := range evm.interpreters {
 		if interpreter.CanRun(contract.Code) {
 			if evm.interpreter != interpreter {
@@ -132,20 +131,27 @@ type EVM struct {
 // NewEVM returns a new EVM. The returned EVM is not thread safe and should
 // only ever be used *once*.
 func NewEVM(ctx Context, statedb StateDB, chainConfig *params.ChainConfig, vmConfig Config) *EVM {
+	if atomic.LoadInt32(&precompilesInUse) == 0 {
+		atomic.StoreInt32(&precompilesInUse, 1)
+	}
 	evm := &EVM{
 		Context:     ctx,
 		StateDB:     statedb,
 		vmConfig:    vmConfig,
 		chainConfig: chainConfig,
 		chainRules:  chainConfig.Rules(ctx.BlockNumber),
-		// The list of interpreters, space reserved for both EVM and EWASM ones.
-		interpreters: make([]Interpreter, 0, 2),
+		// The list of interpreters, space reserved for the custom, EVM and EWASM ones.
+		interpreters: make([]Interpreter, 0, len(vmConfig.Interpreters)+2),
 	}
 
+	for _, factory := range vmConfig.Interpreters {
+		evm.interpreters = append(evm.interpreters, factory(evm, vmConfig))
+	}
 	if chainConfig.IsEWASM(ctx.BlockNumber) {
-		if vmConfig.EWASMInterpreter != "" {
+		switch {
+		case vmConfig.EWASMInterpreter != "":
 			evm.interpreters = append(evm.interpreters, &EVMC{ewasmModule, evm, evmc.CapabilityEWASM, false})
-		} else {
+		case len(vmConfig.Interpreters) == 0:
 			panic("The default ewasm interpreter not supported yet.")
 		}
 	}
@@ -200,11 +206,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		snapshot = evm.StateDB.Snapshot()
 	)
 	if !evm.StateDB.Exist(addr) {
-		precompiles := PrecompiledContractsHomestead
-		if evm.ChainConfig().IsByzantium(evm.BlockNumber) {
-			precompiles = PrecompiledContractsByzantium
-		}
-		if precompiles[addr] == nil && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.Sign() == 0 {
+		if precompiledContract(evm.chainRules, addr) == nil && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.Sign() == 0 {
 			// Calling a non existing account, don't do anything, but ping the tracer
 			if evm.vmConfig.Debug && evm.depth == 0 {
 				evm.vmConfig.Tracer.CaptureStart(caller.Address(), addr, false, input, gas, value)
@@ -397,9 +399,21 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	}
 	evm.Transfer(evm.StateDB, caller.Address(), address, value)
 
+	// Wasm init code needs to be bound by gas just like the deployed code, so
+	// route it through the sentinel contract before running it.
+	contractGas := gas
+	if codeFormat(evm.chainRules, codeAndHash.code) == FormatWasm {
+		metered, leftOverGas, err := evm.validate(AccountRef(address), codeAndHash.code, gas)
+		if err != nil {
+			evm.StateDB.RevertToSnapshot(snapshot)
+			return nil, address, 0, err
+		}
+		codeAndHash.code, codeAndHash.hash = metered, common.Hash{}
+		contractGas = leftOverGas
+	}
 	// Initialise a new contract and set the code that is to be used by the EVM.
 	// The contract is a scoped environment for this execution context only.
-	contract := NewContract(caller, AccountRef(address), value, gas)
+	contract := NewContract(caller, AccountRef(address), value, contractGas)
 	contract.SetCodeOptionalHash(&address, codeAndHash)
 
 	if evm.vmConfig.NoRecursion && evm.depth > 0 {
@@ -413,6 +427,11 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 
 	ret, err := run(evm, contract, nil, false)
 
+	// Deployed wasm code is routed through the sentinel contract before storing
+	// it, charging for the size of the final, metered module.
+	if err == nil && codeFormat(evm.chainRules, ret) == FormatWasm {
+		ret, contract.Gas, err = evm.validate(contract, ret, contract.Gas)
+	}
 	// check whether the max code size has been exceeded
 	//maxCodeSizeExceeded := evm.ChainConfig().IsEIP158(evm.BlockNumber) && len(ret) > params.MaxCodeSize
 	maxCodeSizeExceeded := false
@@ -449,6 +468,21 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 
 }
 
+// validate routes wasm code through the sentinel contract, returning the
+// metered module and reporting the verdict to the tracer if it is interested,
+// whether debugging is enabled or not.
+func (evm *EVM) validate(caller ContractRef, code []byte, gas uint64) ([]byte, uint64, error) {
+	start := time.Now()
+	metered, leftOverGas, err := evm.StaticCall(caller, SentinelAddress, code, gas)
+
+	// The sentinel passed no verdict if it couldn't be called or paid for
+	skipped := (evm.vmConfig.NoRecursion && evm.depth > 0) || err == ErrDepth || err == ErrOutOfGas
+	if tracer, ok := evm.vmConfig.Tracer.(CodeValidationTracer); ok && !skipped {
+		tracer.CaptureCodeValidation(crypto.Keccak256Hash(code), err == nil, err, time.Since(start))
+	}
+	return metered, leftOverGas, err
+}
+
 // Create creates a new contract using code as deployment code.
 func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	contractAddr = crypto.CreateAddress(caller.Address(), evm.StateDB.GetNonce(caller.Address()))
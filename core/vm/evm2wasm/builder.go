@@ -0,0 +1,133 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package evm2wasm
+
+import "github.com/ethereum/go-ethereum/core/vm/ewasm"
+
+// Wasm opcodes emitted by the transcompiler besides the ones the ewasm package
+// already defines.
+const (
+	opLocalSet     byte = 0x21
+	opLocalTee     byte = 0x22
+	opI64Load      byte = 0x29
+	opI32Load8U    byte = 0x2d
+	opI64Store     byte = 0x37
+	opI32Store8    byte = 0x3a
+	opI32LtU       byte = 0x49
+	opI32GeU       byte = 0x4f
+	opI64Eqz       byte = 0x50
+	opI64Eq        byte = 0x51
+	opI64Ne        byte = 0x52
+	opI64LtU       byte = 0x54
+	opI64GtU       byte = 0x56
+	opI32Add       byte = 0x6a
+	opI32Sub       byte = 0x6b
+	opI32And       byte = 0x71
+	opI32Or        byte = 0x72
+	opI64Add       byte = 0x7c
+	opI64Sub       byte = 0x7d
+	opI64And       byte = 0x83
+	opI64Or        byte = 0x84
+	opI64Xor       byte = 0x85
+	opI64ShrU      byte = 0x88
+	opI64Rotl      byte = 0x89
+	opI64ExtendI32 byte = 0xad
+
+	blockEmpty byte = 0x40 // block type of structured instructions without results
+	blockI32   byte = 0x7f // block type of structured instructions yielding an i32
+)
+
+// builder assembles the instruction stream of a single function body.
+type builder struct {
+	code []byte
+}
+
+func (b *builder) op(ops ...byte) *builder {
+	b.code = append(b.code, ops...)
+	return b
+}
+
+func (b *builder) i32(v int32) *builder {
+	b.code = appendSleb(append(b.code, ewasm.OpI32Const), int64(v))
+	return b
+}
+
+func (b *builder) i64(v int64) *builder {
+	b.code = appendSleb(append(b.code, ewasm.OpI64Const), v)
+	return b
+}
+
+// get, set and tee access the local variable with the given index.
+func (b *builder) get(local uint32) *builder { return b.index(ewasm.OpLocalGet, local) }
+func (b *builder) set(local uint32) *builder { return b.index(opLocalSet, local) }
+func (b *builder) tee(local uint32) *builder { return b.index(opLocalTee, local) }
+
+func (b *builder) call(fn uint32) *builder  { return b.index(ewasm.OpCall, fn) }
+func (b *builder) br(depth uint32) *builder { return b.index(ewasm.OpBr, depth) }
+
+func (b *builder) index(op byte, index uint32) *builder {
+	b.code = appendUleb(append(b.code, op), uint64(index))
+	return b
+}
+
+// mem emits a memory access with the given static offset. The alignment hint
+// is always left at byte alignment as EVM values carry no alignment guarantee.
+func (b *builder) mem(op byte, offset uint32) *builder {
+	b.code = appendUleb(append(b.code, op, 0), uint64(offset))
+	return b
+}
+
+// block, loop and when open structured instructions without results, which
+// have to be closed by end.
+func (b *builder) block() *builder { return b.op(ewasm.OpBlock, blockEmpty) }
+func (b *builder) loop() *builder  { return b.op(ewasm.OpLoop, blockEmpty) }
+func (b *builder) when() *builder  { return b.op(ewasm.OpIf, blockEmpty) }
+func (b *builder) end() *builder   { return b.op(ewasm.OpEnd) }
+
+// brTable emits a jump through the given branch targets, the last element
+// being the default.
+func (b *builder) brTable(targets []uint32) *builder {
+	b.code = appendUleb(append(b.code, ewasm.OpBrTable), uint64(len(targets)-1))
+	for _, target := range targets {
+		b.code = appendUleb(b.code, uint64(target))
+	}
+	return b
+}
+
+func appendUleb(out []byte, v uint64) []byte {
+	for {
+		c := byte(v & 0x7f)
+		if v >>= 7; v != 0 {
+			c |= 0x80
+		}
+		out = append(out, c)
+		if v == 0 {
+			return out
+		}
+	}
+}
+
+func appendSleb(out []byte, v int64) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(out, c)
+		}
+		out = append(out, c|0x80)
+	}
+}
@@ -0,0 +1,427 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package evm2wasm implements a transcompiler from EVM bytecode to ewasm
+// contracts.
+//
+// Only a restricted subset of the EVM is supported: stack manipulation, the
+// basic arithmetic, comparison and bitwise operations, hashing, memory and
+// storage access, call data and a few environmental opcodes. Jumps have to be static,
+// that is their destination needs to be pushed right before the jump. Gas is
+// not accounted for by the generated code, ewasm metering takes care of that
+// upon deployment.
+package evm2wasm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/ewasm"
+)
+
+// ErrDynamicJump is returned if the destination of a jump is not a constant
+// pushed right before it.
+var ErrDynamicJump = errors.New("evm2wasm: dynamic jumps are not supported")
+
+// UnsupportedOpError is returned if the code contains an opcode the
+// transcompiler can't translate.
+type UnsupportedOpError struct {
+	Op vm.OpCode
+	Pc uint64
+}
+
+func (e *UnsupportedOpError) Error() string {
+	return fmt.Sprintf("evm2wasm: unsupported opcode %v at pc %d", e.Op, e.Pc)
+}
+
+// Locals of the main function.
+const (
+	localSp uint32 = iota // Offset of the first free stack slot
+	localPc               // Index of the segment to continue at
+)
+
+// Transcompile translates EVM bytecode into an encoded ewasm contract.
+func Transcompile(code []byte) ([]byte, error) {
+	m, err := Compile(code)
+	if err != nil {
+		return nil, err
+	}
+	return m.Encode(), nil
+}
+
+// Compile translates EVM bytecode into an ewasm module.
+//
+// The code is cut into segments at every jump destination. All segments are
+// laid out within a dispatch loop, nested into blocks so that each one can be
+// entered through a single br_table, falling through into the next segment
+// like the EVM does. A jump sets the index of its destination segment and
+// restarts the loop.
+func Compile(code []byte) (*ewasm.Module, error) {
+	c := &compiler{code: code, starts: []uint64{0}, segments: make(map[uint64]uint32)}
+	for pc := uint64(0); pc < uint64(len(code)); pc = next(code, pc) {
+		if vm.OpCode(code[pc]) != vm.JUMPDEST {
+			continue
+		}
+		if pc > 0 {
+			c.starts = append(c.starts, pc)
+		}
+		c.segments[pc] = uint32(len(c.starts) - 1)
+	}
+	body, err := c.compile()
+	if err != nil {
+		return nil, err
+	}
+	return assemble(body, c.keccak), nil
+}
+
+// compiler holds the state of translating a single piece of code.
+type compiler struct {
+	code     []byte
+	starts   []uint64          // Program counters at which segments start
+	segments map[uint64]uint32 // Segment indices of the jump destinations
+	keccak   bool              // Whether the code hashes, needing the keccak helpers
+}
+
+func (c *compiler) compile() ([]byte, error) {
+	var (
+		b     = new(builder)
+		count = uint32(len(c.starts))
+	)
+	b.loop()
+	targets := make([]uint32, count)
+	for i := range targets {
+		b.block()
+		targets[i] = uint32(i)
+	}
+	b.get(localPc).brTable(append(targets, count-1))
+	for i, start := range c.starts {
+		b.end()
+
+		end := uint64(len(c.code))
+		if i+1 < len(c.starts) {
+			end = c.starts[i+1]
+		}
+		if err := c.segment(b, start, end, count-1-uint32(i)); err != nil {
+			return nil, err
+		}
+	}
+	return b.end().end().code, nil
+}
+
+// segment translates the code between start and end. The depth is the number
+// of structured instructions enclosing the segment within the dispatch loop.
+func (c *compiler) segment(b *builder, start, end uint64, depth uint32) error {
+	for pc := start; pc < end; pc = next(c.code, pc) {
+		op := vm.OpCode(c.code[pc])
+		switch {
+		case op >= vm.PUSH1 && op <= vm.PUSH32:
+			data := pushData(c.code, pc+1, uint64(op-vm.PUSH1)+1)
+
+			// Static jumps consume the pushed destination directly
+			if jump := next(c.code, pc); jump < end {
+				switch vm.OpCode(c.code[jump]) {
+				case vm.JUMP:
+					c.jump(b, new(big.Int).SetBytes(data), depth)
+					pc = c.skip(jump, end)
+					continue
+				case vm.JUMPI:
+					adjust(b, -32)
+					b.get(localSp).call(fnIsZero).op(ewasm.OpI32Eqz).when()
+					c.jump(b, new(big.Int).SetBytes(data), depth+1)
+					b.end()
+					pc = jump
+					continue
+				}
+			}
+			grow(b)
+			word := common.LeftPadBytes(data, 32)
+			for k := uint32(0); k < 4; k++ {
+				limb := binary.BigEndian.Uint64(word[24-8*k:])
+				b.get(localSp).i64(int64(limb)).mem(opI64Store, 8*k)
+			}
+			adjust(b, 32)
+
+		case op >= vm.DUP1 && op <= vm.DUP16:
+			grow(b)
+			for k := uint32(0); k < 32; k += 8 {
+				b.get(localSp)
+				slot(b, int(op-vm.DUP1)+1).mem(opI64Load, k).mem(opI64Store, k)
+			}
+			adjust(b, 32)
+
+		case op >= vm.SWAP1 && op <= vm.SWAP16:
+			slot(b, 1)
+			slot(b, int(op-vm.SWAP1)+2).call(fnSwap)
+
+		case op == vm.STOP:
+			b.op(ewasm.OpReturn)
+			pc = c.skip(pc, end)
+
+		case op == vm.JUMPDEST:
+
+		case op == vm.POP:
+			adjust(b, -32)
+
+		case op == vm.ADD || op == vm.SUB:
+			slot(b, 1)
+			slot(b, 2)
+			if op == vm.ADD {
+				b.call(fnAdd)
+			} else {
+				b.call(fnSub)
+			}
+			adjust(b, -32)
+
+		case op == vm.LT || op == vm.GT || op == vm.EQ:
+			slot(b, 2)
+			switch op {
+			case vm.LT:
+				slot(b, 1)
+				slot(b, 2).call(fnLt)
+			case vm.GT:
+				slot(b, 2)
+				slot(b, 1).call(fnLt)
+			case vm.EQ:
+				slot(b, 1)
+				slot(b, 2).call(fnEq)
+			}
+			setBool(b, 2)
+			adjust(b, -32)
+
+		case op == vm.ISZERO:
+			slot(b, 1)
+			slot(b, 1).call(fnIsZero)
+			setBool(b, 1)
+
+		case op == vm.AND || op == vm.OR || op == vm.XOR:
+			instr := map[vm.OpCode]byte{vm.AND: opI64And, vm.OR: opI64Or, vm.XOR: opI64Xor}[op]
+			for k := uint32(0); k < 32; k += 8 {
+				slot(b, 2)
+				slot(b, 1).mem(opI64Load, k)
+				slot(b, 2).mem(opI64Load, k).op(instr).mem(opI64Store, k)
+			}
+			adjust(b, -32)
+
+		case op == vm.NOT:
+			for k := uint32(0); k < 32; k += 8 {
+				slot(b, 1)
+				slot(b, 1).mem(opI64Load, k).i64(-1).op(opI64Xor).mem(opI64Store, k)
+			}
+
+		case op == vm.MLOAD:
+			b.i32(memoryOffset)
+			slot(b, 1).call(fnOffset).op(opI32Add)
+			slot(b, 1).call(fnSwapBytes)
+
+		case op == vm.MSTORE:
+			slot(b, 2)
+			b.i32(memoryOffset)
+			slot(b, 1).call(fnOffset).op(opI32Add).call(fnSwapBytes)
+			adjust(b, -64)
+
+		case op == vm.SLOAD:
+			slot(b, 1).i32(scratchOffset).call(fnSwapBytes)
+			b.i32(scratchOffset).i32(scratchOffset + 32).call(fnStorageLoad)
+			b.i32(scratchOffset + 32)
+			slot(b, 1).call(fnSwapBytes)
+
+		case op == vm.SSTORE:
+			slot(b, 1).i32(scratchOffset).call(fnSwapBytes)
+			slot(b, 2).i32(scratchOffset + 32).call(fnSwapBytes)
+			b.i32(scratchOffset).i32(scratchOffset + 32).call(fnStorageStore)
+			adjust(b, -64)
+
+		case op == vm.SHA3:
+			b.i32(memoryOffset)
+			slot(b, 1).call(fnOffset).op(opI32Add)
+			slot(b, 2).call(fnOffset)
+			slot(b, 2).call(fnKeccak)
+			adjust(b, -32)
+			c.keccak = true
+
+		case op == vm.CALLER || op == vm.ADDRESS:
+			grow(b)
+			zeroScratch(b)
+			b.i32(scratchOffset + 12)
+			if op == vm.CALLER {
+				b.call(fnGetCaller)
+			} else {
+				b.call(fnGetAddress)
+			}
+			b.i32(scratchOffset).get(localSp).call(fnSwapBytes)
+			adjust(b, 32)
+
+		case op == vm.CALLDATASIZE:
+			grow(b)
+			b.get(localSp).call(fnGetCallDataSize).op(opI64ExtendI32).mem(opI64Store, 0)
+			for k := uint32(8); k < 32; k += 8 {
+				b.get(localSp).i64(0).mem(opI64Store, k)
+			}
+			adjust(b, 32)
+
+		case op == vm.CALLDATALOAD:
+			slot(b, 1).call(fnCallDataLoad)
+
+		case op == vm.RETURN || op == vm.REVERT:
+			b.i32(memoryOffset)
+			slot(b, 1).call(fnOffset).op(opI32Add)
+			slot(b, 2).call(fnOffset)
+			if op == vm.RETURN {
+				b.call(fnFinish)
+			} else {
+				b.call(fnRevert)
+			}
+			b.op(ewasm.OpReturn)
+			pc = c.skip(pc, end)
+
+		case op == 0xfe: // designated invalid instruction
+			b.op(ewasm.OpUnreachable)
+			pc = c.skip(pc, end)
+
+		case op == vm.JUMP || op == vm.JUMPI:
+			return ErrDynamicJump
+
+		default:
+			return &UnsupportedOpError{Op: op, Pc: pc}
+		}
+	}
+	return nil
+}
+
+// jump emits a jump to the segment starting at dest, or a trap if there is no
+// jump destination at that position.
+func (c *compiler) jump(b *builder, dest *big.Int, depth uint32) {
+	if !dest.IsUint64() {
+		b.op(ewasm.OpUnreachable)
+		return
+	}
+	index, ok := c.segments[dest.Uint64()]
+	if !ok {
+		b.op(ewasm.OpUnreachable)
+		return
+	}
+	b.i32(int32(index)).set(localPc).br(depth)
+}
+
+// skip returns the position of the last instruction before end, so that the
+// code following a halting instruction up to the next segment is discarded.
+// It is never executed and might not even be code.
+func (c *compiler) skip(pc, end uint64) uint64 {
+	for n := next(c.code, pc); n < end; n = next(c.code, n) {
+		pc = n
+	}
+	return pc
+}
+
+// next returns the position of the instruction following the one at pc.
+func next(code []byte, pc uint64) uint64 {
+	if op := vm.OpCode(code[pc]); op >= vm.PUSH1 && op <= vm.PUSH32 {
+		return pc + uint64(op-vm.PUSH1) + 2
+	}
+	return pc + 1
+}
+
+// pushData returns the immediate of a push instruction, padded with zeroes if
+// it exceeds the code.
+func pushData(code []byte, start, size uint64) []byte {
+	data := make([]byte, size)
+	if start < uint64(len(code)) {
+		copy(data, code[start:])
+	}
+	return data
+}
+
+// slot pushes the address of the n-th stack item, counting from the top.
+func slot(b *builder, n int) *builder {
+	return b.get(localSp).i32(int32(32 * n)).op(opI32Sub)
+}
+
+// adjust moves the stack pointer by the given number of bytes.
+func adjust(b *builder, delta int32) {
+	b.get(localSp).i32(delta).op(opI32Add).set(localSp)
+}
+
+// grow traps if there is no room for another stack item.
+func grow(b *builder) {
+	b.get(localSp).i32(stackLimit).op(opI32GeU).when().op(ewasm.OpUnreachable).end()
+}
+
+// setBool stores the i32 boolean on top of the wasm stack into the first limb
+// of the n-th stack item, whose address has to be pushed before it, and clears
+// the remaining limbs.
+func setBool(b *builder, n int) {
+	b.op(opI64ExtendI32).mem(opI64Store, 0)
+	for k := uint32(8); k < 32; k += 8 {
+		slot(b, n).i64(0).mem(opI64Store, k)
+	}
+}
+
+// assemble wraps the main function body along with the runtime helpers into
+// an ewasm contract, including the hashing helpers if requested.
+func assemble(main []byte, keccak bool) *ewasm.Module {
+	m := &ewasm.Module{
+		Memories: []ewasm.Limits{{Min: memoryPages}},
+		Exports: []ewasm.Export{
+			{Name: ewasm.MemoryExport, Kind: ewasm.ExternalMemory, Index: 0},
+			{Name: ewasm.MainExport, Kind: ewasm.ExternalFunction, Index: fnMain},
+		},
+	}
+	typeIndex := func(ft ewasm.FuncType) uint32 {
+		for i, t := range m.Types {
+			if t.Equal(ft) {
+				return uint32(i)
+			}
+		}
+		m.Types = append(m.Types, ft)
+		return uint32(len(m.Types) - 1)
+	}
+	for _, name := range imports {
+		m.Imports = append(m.Imports, ewasm.Import{
+			Module:    ewasm.EEIModule,
+			Field:     name,
+			Kind:      ewasm.ExternalFunction,
+			TypeIndex: typeIndex(ewasm.EEI[name]),
+		})
+	}
+	define := func(h helper) {
+		b := new(builder)
+		h.body(b)
+
+		m.Functions = append(m.Functions, typeIndex(h.typ))
+		m.Code = append(m.Code, ewasm.Body{Locals: h.locals, Code: b.end().code})
+	}
+	for _, h := range helpers {
+		define(h)
+	}
+	m.Functions = append(m.Functions, typeIndex(ewasm.FuncType{}))
+	m.Code = append(m.Code, ewasm.Body{Locals: []ewasm.Local{{Count: 2, Type: ewasm.I32}}, Code: main})
+
+	if keccak {
+		for _, h := range keccakHelpers {
+			define(h)
+		}
+		rounds := make([]byte, 8*len(keccakRoundConstants))
+		for i, rc := range keccakRoundConstants {
+			binary.LittleEndian.PutUint64(rounds[8*i:], rc)
+		}
+		m.Data = append(m.Data, ewasm.Data{Offset: new(builder).i32(keccakRounds).end().code, Init: rounds})
+	}
+	return m
+}
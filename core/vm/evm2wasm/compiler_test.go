@@ -0,0 +1,215 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package evm2wasm
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/ewasm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that transcompiled contracts are accepted by the sentinel contract.
+func TestTranscompile(t *testing.T) {
+	tests := []string{
+		// Empty code
+		"0x",
+		// Count to 10 in a loop and return the counter
+		"0x6000" + "5b" + "600101" + "8080600a11" + "600257" + "600052" + "60206000f3",
+		// Store calldata, load it back and revert with it
+		"0x600035" + "600055" + "600054" + "600052" + "60206000fd",
+		// Bitwise operations on the caller and address
+		"0x33" + "30" + "18" + "19" + "600116" + "600017" + "15" + "50" + "00",
+		// Jump into a trailing destination, then past the end of the code
+		"0x600856" + "600160ff" + "fe" + "5b" + "6001" + "600f" + "57" + "5b" + "7f",
+	}
+	for i, tt := range tests {
+		bin, err := Transcompile(common.FromHex(tt))
+		if err != nil {
+			t.Errorf("test %d: failed to transcompile: %v", i, err)
+			continue
+		}
+		m, err := ewasm.Decode(bin)
+		if err != nil {
+			t.Errorf("test %d: failed to decode output: %v", i, err)
+			continue
+		}
+		if err := ewasm.Validate(m); err != nil {
+			t.Errorf("test %d: output rejected: %v", i, err)
+		}
+		if _, err := ewasm.ValidateAndMeter(bin); err != nil {
+			t.Errorf("test %d: failed to meter output: %v", i, err)
+		}
+	}
+}
+
+// Tests that code outside of the supported subset is rejected.
+func TestTranscompileErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		err  error
+	}{
+		{"0x56", ErrDynamicJump},
+		{"0x6001" + "600035" + "57", ErrDynamicJump},
+		{"0x6002600302", &UnsupportedOpError{Op: vm.MUL, Pc: 4}},
+		{"0x5b" + "f0", &UnsupportedOpError{Op: vm.CREATE, Pc: 1}},
+		// Unsupported opcodes in unreachable code are ignored
+		{"0x00" + "f0", nil},
+	}
+	for i, tt := range tests {
+		_, err := Transcompile(common.FromHex(tt.code))
+		if !reflect.DeepEqual(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// words assembles code evaluating every expression in turn and returning their
+// results as consecutive words of memory.
+func words(exprs ...string) string {
+	code := "0x"
+	for i, expr := range exprs {
+		code += expr + fmt.Sprintf("61%04x52", 32*i)
+	}
+	return code + fmt.Sprintf("61%04x6000f3", 32*len(exprs))
+}
+
+// outcome is the observable result of executing a contract.
+type outcome struct {
+	status  string // ok, revert or fail
+	output  []byte
+	storage map[common.Hash]common.Hash
+}
+
+func (o outcome) String() string {
+	return fmt.Sprintf("%s %x %x", o.status, o.output, o.storage)
+}
+
+// Tests that transcompiled contracts behave like the EVM bytecode they were
+// derived from, comparing the output, the way the execution ended and the
+// storage left behind.
+func TestExecution(t *testing.T) {
+	var (
+		max     = strings.Repeat("ff", 32)
+		pattern = "0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20"
+	)
+	// Nine words of the pattern laid out in memory, spanning two hash blocks
+	var spread string
+	for i := 0; i < 9; i++ {
+		spread += "7f" + pattern + fmt.Sprintf("61%04x52", 0x1000+32*i)
+	}
+	tests := []struct {
+		name  string
+		code  string
+		input string
+	}{
+		// Arithmetic and comparisons, with carries and borrows across limbs
+		{"add", words("7f"+max+"600201", "68"+strings.Repeat("ff", 9)+"600101", "7f"+pattern+"7f"+max+"01"), ""},
+		{"sub", words("6001600003", "600169"+"01"+strings.Repeat("00", 9)+"03", "7f"+pattern+"600003"), ""},
+		{"compare", words("6002600110", "6001600210", "6002600111", "68"+"01"+strings.Repeat("00", 8)+"600111", "7f"+max+"7f"+max+"14", "680100000000000000006000"+"14"), ""},
+		{"bitwise", words("600015", "68"+"01"+strings.Repeat("00", 8)+"15", "7f"+pattern+"7f"+max+"16", "7f"+pattern+"600f17", "7f"+pattern+"7f"+pattern+"18", "600019", "7f"+pattern+"19"), ""},
+		{"stack", words("6001600260039091"+"50"+"50", "600160026003"+"82"+"9150"+"9150"), ""},
+		// Memory accesses at unaligned offsets, overlapping and untouched words
+		{"memory", words("7f"+pattern+"6110055261100051", "61101051", "61200051", "7f"+pattern+"60ff61100152"+"61100051"), ""},
+		{"memory-return", "0x7f" + pattern + "600052" + "60076003f3", ""},
+		{"memory-limit", "0x63ffffffff51", ""},
+		// Static jumps, taken and not taken, and invalid destinations
+		{"loop", "0x6000" + "5b" + "600101" + "8080600a11" + "600257" + "600052" + "60206000f3", ""},
+		{"branch-taken", "0x600035600b57" + "6001600e56" + "5b6002" + "5b600052" + "60206000f3", "01"},
+		{"branch-skipped", "0x600035600b57" + "6001600e56" + "5b6002" + "5b600052" + "60206000f3", "00"},
+		{"jump-no-dest", "0x600356005b", ""},
+		{"jump-push-data", "0x600456605b00", ""},
+		{"invalid", "0xfe", ""},
+		// Storage writes, overwrites, deletions and reads
+		{"storage", words("602a600155600154", "6007335533"+"54", "606360025560006002556002"+"54", "600554", "600160015560015460025401"), ""},
+		{"storage-revert", "0x602a600155" + "60206000fd", ""},
+		{"storage-fail", "0x602a600155" + "fe", ""},
+		// Hashing of single and multiple blocks, at unaligned offsets
+		{"keccak", words("60006000"+"20", "7f"+pattern+"611000526020611000"+"20", "7f"+pattern+"611018526087611005"+"20", "6088611000"+"20", "6089611001"+"20", "61012c611000"+"20"), ""},
+		{"keccak-blocks", words(spread + "60c8611003" + "20"), ""},
+		{"keccak-limit", "0x600163ffffffff20", ""},
+		// Call data and environment
+		{"calldata", words("600035", "600435", "601f35", "604035", "36", "33", "30"), pattern + "aabbccdd"},
+		{"calldata-empty", words("600035", "36"), ""},
+	}
+	var (
+		caller  = common.HexToAddress("0xca11e4")
+		address = common.BytesToAddress([]byte("contract"))
+	)
+	for _, tt := range tests {
+		code, input := common.FromHex(tt.code), common.FromHex(tt.input)
+
+		// Execute the bytecode on the EVM, tracking the written storage slots
+		logger := vm.NewStructLogger(nil)
+		ret, statedb, err := runtime.Execute(code, input, &runtime.Config{
+			ChainConfig: params.AllEthashProtocolChanges,
+			Origin:      caller,
+			GasLimit:    10000000,
+			EVMConfig:   vm.Config{Debug: true, Tracer: logger},
+		})
+		evm := outcome{status: "ok", output: ret, storage: make(map[common.Hash]common.Hash)}
+		if err != nil {
+			evm.status = "fail"
+			if err.Error() == "evm: execution reverted" {
+				evm.status = "revert"
+			}
+		}
+		for _, log := range logger.StructLogs() {
+			if log.Op == vm.SSTORE {
+				key := common.BigToHash(log.Stack[len(log.Stack)-1])
+				if value := statedb.GetState(address, key); value != (common.Hash{}) {
+					evm.storage[key] = value
+				}
+			}
+		}
+		// Execute the transcompiled contract
+		m, err := Compile(code)
+		if err != nil {
+			t.Errorf("%s: failed to transcompile: %v", tt.name, err)
+			continue
+		}
+		machine := newMachine(m, caller, address, input)
+		wasm := outcome{status: "ok", storage: make(map[common.Hash]common.Hash)}
+		switch err := machine.run(); {
+		case err == errTrap:
+			wasm.status = "fail"
+		case err != nil:
+			t.Errorf("%s: failed to execute: %v", tt.name, err)
+			continue
+		case machine.reverted:
+			wasm.status = "revert"
+		}
+		if wasm.status != "fail" {
+			wasm.output = machine.output
+		}
+		for key, value := range machine.storage {
+			if value != (common.Hash{}) {
+				wasm.storage[key] = value
+			}
+		}
+		if wasm.status != evm.status || !bytes.Equal(wasm.output, evm.output) || !reflect.DeepEqual(wasm.storage, evm.storage) {
+			t.Errorf("%s: outcome mismatch:\nwasm: %v\nevm:  %v", tt.name, wasm, evm)
+		}
+	}
+}
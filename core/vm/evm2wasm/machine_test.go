@@ -0,0 +1,458 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package evm2wasm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm/ewasm"
+)
+
+var (
+	errTrap = errors.New("wasm: trap")
+	errHalt = errors.New("wasm: halted") // Execution ended through finish or revert
+)
+
+// machine is a minimal wasm interpreter, capable of just the instructions and
+// host functions the transcompiler emits. It executes contracts in a mock
+// environment so they can be checked against the EVM.
+type machine struct {
+	module *ewasm.Module
+	memory []byte
+	ends   map[uint32]map[int]int // Positions following the end of every block, by function
+
+	caller   common.Address
+	address  common.Address
+	input    []byte
+	storage  map[common.Hash]common.Hash
+	output   []byte
+	reverted bool
+}
+
+// newMachine instantiates the module, which has to define a single memory.
+// Data segments have to be placed at constant offsets.
+func newMachine(m *ewasm.Module, caller, address common.Address, input []byte) *machine {
+	in := &machine{
+		module:  m,
+		memory:  make([]byte, 65536*int(m.Memories[0].Min)),
+		ends:    make(map[uint32]map[int]int),
+		caller:  caller,
+		address: address,
+		input:   input,
+		storage: make(map[common.Hash]common.Hash),
+	}
+	for _, data := range m.Data {
+		offset, _ := sleb(data.Offset[1:])
+		copy(in.memory[offset:], data.Init)
+	}
+	return in
+}
+
+// run executes the exported main function. Storage writes are discarded if
+// the contract reverts or traps.
+func (in *machine) run() error {
+	for _, export := range in.module.Exports {
+		if export.Name == ewasm.MainExport && export.Kind == ewasm.ExternalFunction {
+			_, err := in.call(export.Index, nil)
+			if err == errHalt {
+				err = nil
+			}
+			if err != nil || in.reverted {
+				in.storage = make(map[common.Hash]common.Hash)
+			}
+			return err
+		}
+	}
+	return errors.New("wasm: no main function")
+}
+
+// call invokes the function with the given index, returning its results.
+func (in *machine) call(fn uint32, args []uint64) ([]uint64, error) {
+	imported := in.module.NumImportedFuncs()
+	if fn < imported {
+		return in.host(in.module.Imports[fn].Field, args)
+	}
+	var (
+		typ    = in.module.FuncType(fn)
+		body   = in.module.Code[fn-imported]
+		locals = append([]uint64{}, args...)
+	)
+	for _, local := range body.Locals {
+		locals = append(locals, make([]uint64, local.Count)...)
+	}
+	if in.ends[fn] == nil {
+		ends, err := blockEnds(body.Code)
+		if err != nil {
+			return nil, err
+		}
+		in.ends[fn] = ends
+	}
+	return in.exec(body.Code, in.ends[fn], locals, len(typ.Results))
+}
+
+// label is a structured instruction being executed.
+type label struct {
+	loop   bool
+	start  int // Position of the first instruction within the block
+	end    int // Position following the end of the block
+	height int // Height of the operand stack upon entering the block
+	arity  int // Number of values the block yields when branched out of
+}
+
+// exec interprets a function body.
+func (in *machine) exec(code []byte, ends map[int]int, locals []uint64, results int) ([]uint64, error) {
+	var (
+		stack  []uint64
+		labels = []label{{end: len(code), arity: results}}
+	)
+	push := func(v uint64) { stack = append(stack, v) }
+	pop := func() uint64 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+	// branch leaves the given number of enclosing blocks, continuing after the
+	// outermost one or at the top of it if it's a loop.
+	branch := func(depth int) int {
+		target := labels[len(labels)-1-depth]
+		if target.loop {
+			stack = stack[:target.height]
+			labels = labels[:len(labels)-depth]
+			return target.start
+		}
+		values := append([]uint64{}, stack[len(stack)-target.arity:]...)
+		stack = append(stack[:target.height], values...)
+		labels = labels[:len(labels)-1-depth]
+		return target.end
+	}
+	for pc := 0; len(labels) > 0; {
+		if pc == len(code) {
+			return stack[len(stack)-results:], nil
+		}
+		op := code[pc]
+		pc++
+
+		switch op {
+		case ewasm.OpUnreachable:
+			return nil, errTrap
+
+		case ewasm.OpBlock, ewasm.OpLoop, ewasm.OpIf:
+			arity := 0
+			if code[pc] != blockEmpty {
+				arity = 1
+			}
+			start, end := pc+1, ends[pc-1]
+			if op == ewasm.OpIf && uint32(pop()) == 0 {
+				pc = end
+				continue
+			}
+			labels = append(labels, label{loop: op == ewasm.OpLoop, start: start, end: end, height: len(stack), arity: arity})
+			pc = start
+
+		case ewasm.OpEnd:
+			labels = labels[:len(labels)-1]
+
+		case ewasm.OpBr:
+			depth, n := uleb(code[pc:])
+			pc += n
+			pc = branch(int(depth))
+
+		case ewasm.OpBrIf:
+			depth, n := uleb(code[pc:])
+			pc += n
+			if uint32(pop()) != 0 {
+				pc = branch(int(depth))
+			}
+
+		case ewasm.OpBrTable:
+			count, n := uleb(code[pc:])
+			pc += n
+			targets := make([]uint64, count+1)
+			for i := range targets {
+				targets[i], n = uleb(code[pc:])
+				pc += n
+			}
+			index := uint64(uint32(pop()))
+			if index > count {
+				index = count
+			}
+			pc = branch(int(targets[index]))
+
+		case ewasm.OpReturn:
+			return stack[len(stack)-results:], nil
+
+		case ewasm.OpCall:
+			fn, n := uleb(code[pc:])
+			pc += n
+			typ := in.module.FuncType(uint32(fn))
+			if typ == nil {
+				return nil, fmt.Errorf("wasm: unknown function %d", fn)
+			}
+			args := append([]uint64{}, stack[len(stack)-len(typ.Params):]...)
+			stack = stack[:len(stack)-len(typ.Params)]
+			res, err := in.call(uint32(fn), args)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, res...)
+
+		case ewasm.OpSelect:
+			cond, b, a := uint32(pop()), pop(), pop()
+			if cond != 0 {
+				push(a)
+			} else {
+				push(b)
+			}
+
+		case ewasm.OpLocalGet, opLocalSet, opLocalTee:
+			index, n := uleb(code[pc:])
+			pc += n
+			switch op {
+			case ewasm.OpLocalGet:
+				push(locals[index])
+			case opLocalSet:
+				locals[index] = pop()
+			case opLocalTee:
+				locals[index] = stack[len(stack)-1]
+			}
+
+		case ewasm.OpI32Load, opI64Load, opI32Load8U, opI64Store, opI32Store8:
+			_, n := uleb(code[pc:]) // alignment hint
+			pc += n
+			offset, n := uleb(code[pc:])
+			pc += n
+
+			var value uint64
+			if op == opI64Store || op == opI32Store8 {
+				value = pop()
+			}
+			addr := uint64(uint32(pop())) + offset
+			size := map[byte]uint64{ewasm.OpI32Load: 4, opI64Load: 8, opI32Load8U: 1, opI64Store: 8, opI32Store8: 1}[op]
+			if addr+size > uint64(len(in.memory)) {
+				return nil, errTrap
+			}
+			mem := in.memory[addr : addr+size]
+			switch op {
+			case ewasm.OpI32Load:
+				push(uint64(binary.LittleEndian.Uint32(mem)))
+			case opI64Load:
+				push(binary.LittleEndian.Uint64(mem))
+			case opI32Load8U:
+				push(uint64(mem[0]))
+			case opI64Store:
+				binary.LittleEndian.PutUint64(mem, value)
+			case opI32Store8:
+				mem[0] = byte(value)
+			}
+
+		case ewasm.OpI32Const:
+			v, n := sleb(code[pc:])
+			pc += n
+			push(uint64(uint32(v)))
+
+		case ewasm.OpI64Const:
+			v, n := sleb(code[pc:])
+			pc += n
+			push(uint64(v))
+
+		case ewasm.OpI32Eqz, opI64Eqz:
+			push(boolean(pop() == 0))
+
+		case opI64ExtendI32:
+			// Operands are kept zero extended already
+
+		default:
+			b, a := pop(), pop()
+			switch op {
+			case opI32LtU:
+				push(boolean(uint32(a) < uint32(b)))
+			case opI32GeU:
+				push(boolean(uint32(a) >= uint32(b)))
+			case opI32Add:
+				push(uint64(uint32(a + b)))
+			case opI32Sub:
+				push(uint64(uint32(a - b)))
+			case opI32And:
+				push(uint64(uint32(a & b)))
+			case opI32Or:
+				push(uint64(uint32(a | b)))
+			case opI64Eq:
+				push(boolean(a == b))
+			case opI64Ne:
+				push(boolean(a != b))
+			case opI64LtU:
+				push(boolean(a < b))
+			case opI64GtU:
+				push(boolean(a > b))
+			case opI64Add:
+				push(a + b)
+			case opI64Sub:
+				push(a - b)
+			case opI64And:
+				push(a & b)
+			case opI64Or:
+				push(a | b)
+			case opI64Xor:
+				push(a ^ b)
+			case opI64ShrU:
+				push(a >> (b % 64))
+			case opI64Rotl:
+				push(bits.RotateLeft64(a, int(b%64)))
+			default:
+				return nil, ewasm.ErrUnknownOpcode(op)
+			}
+		}
+	}
+	return stack[len(stack)-results:], nil
+}
+
+// host executes an EEI function against the mock environment.
+func (in *machine) host(name string, args []uint64) ([]uint64, error) {
+	mem := func(offset, size uint64) ([]byte, error) {
+		if offset+size > uint64(len(in.memory)) {
+			return nil, errTrap
+		}
+		return in.memory[offset : offset+size], nil
+	}
+	switch name {
+	case "storageStore", "storageLoad":
+		key, err := mem(args[0], 32)
+		if err != nil {
+			return nil, err
+		}
+		value, err := mem(args[1], 32)
+		if err != nil {
+			return nil, err
+		}
+		if name == "storageStore" {
+			in.storage[common.BytesToHash(key)] = common.BytesToHash(value)
+		} else {
+			stored := in.storage[common.BytesToHash(key)]
+			copy(value, stored[:])
+		}
+	case "getCaller", "getAddress":
+		dst, err := mem(args[0], common.AddressLength)
+		if err != nil {
+			return nil, err
+		}
+		if name == "getCaller" {
+			copy(dst, in.caller[:])
+		} else {
+			copy(dst, in.address[:])
+		}
+	case "getCallDataSize":
+		return []uint64{uint64(len(in.input))}, nil
+	case "callDataCopy":
+		dst, err := mem(args[0], args[2])
+		if err != nil {
+			return nil, err
+		}
+		if args[1]+args[2] > uint64(len(in.input)) {
+			return nil, errTrap
+		}
+		copy(dst, in.input[args[1]:])
+	case "finish", "revert":
+		data, err := mem(args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+		in.output = append([]byte{}, data...)
+		in.reverted = name == "revert"
+		return nil, errHalt
+	default:
+		return nil, fmt.Errorf("wasm: unsupported host function %s", name)
+	}
+	return nil, nil
+}
+
+// blockEnds maps the position of every structured instruction of the code to
+// the position following its end.
+func blockEnds(code []byte) (map[int]int, error) {
+	var (
+		ends  = make(map[int]int)
+		opens []int
+	)
+	for pc := 0; pc < len(code); {
+		op := code[pc]
+		switch op {
+		case ewasm.OpBlock, ewasm.OpLoop, ewasm.OpIf:
+			opens = append(opens, pc)
+			pc += 2
+			continue
+		case ewasm.OpElse:
+			return nil, errors.New("wasm: else is not supported")
+		case ewasm.OpEnd:
+			if len(opens) > 0 {
+				ends[opens[len(opens)-1]] = pc + 1
+				opens = opens[:len(opens)-1]
+			}
+		}
+		pc++
+		switch {
+		case op == ewasm.OpBr || op == ewasm.OpBrIf || op == ewasm.OpCall || op == ewasm.OpLocalGet ||
+			op == opLocalSet || op == opLocalTee || op == ewasm.OpI32Const || op == ewasm.OpI64Const:
+			_, n := uleb(code[pc:])
+			pc += n
+		case op == ewasm.OpBrTable:
+			count, n := uleb(code[pc:])
+			pc += n
+			for i := uint64(0); i <= count; i++ {
+				_, n = uleb(code[pc:])
+				pc += n
+			}
+		case op >= ewasm.OpI32Load && op <= ewasm.OpI64Store32:
+			for i := 0; i < 2; i++ {
+				_, n := uleb(code[pc:])
+				pc += n
+			}
+		}
+	}
+	return ends, nil
+}
+
+// uleb decodes an unsigned LEB128 number, returning it along with its length.
+// Signed numbers are skipped just the same, so it's used for those too.
+func uleb(buf []byte) (uint64, int) {
+	var v uint64
+	for i, b := range buf {
+		v |= uint64(b&0x7f) << (7 * uint(i))
+		if b&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v, len(buf)
+}
+
+// sleb decodes a signed LEB128 number, returning it along with its length.
+func sleb(buf []byte) (int64, int) {
+	v, n := uleb(buf)
+	if shift := uint(7 * n); shift < 64 && buf[n-1]&0x40 != 0 {
+		v |= ^uint64(0) << shift
+	}
+	return int64(v), n
+}
+
+func boolean(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,322 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package evm2wasm
+
+import "github.com/ethereum/go-ethereum/core/vm/ewasm"
+
+// Layout of the linear memory of a transcompiled contract. The EVM stack grows
+// upwards from the bottom of the memory, with every slot holding a 256 bit
+// word as four little endian 64 bit limbs, least significant limb first. The
+// EVM memory is mapped verbatim at its own page-aligned offset.
+const (
+	stackLimit    = 1024 * 32          // End of the EVM stack
+	scratchOffset = stackLimit         // Two words of scratch space for host calls
+	keccakState   = scratchOffset + 64 // Keccak-f[1600] state of the hash being computed
+	keccakBlock   = keccakState + 200  // Padded final block of the hashed data
+	keccakRounds  = keccakBlock + 136  // Round constants of keccak-f[1600]
+	memoryOffset  = 64 * 1024          // Start of the EVM memory
+	memoryPages   = 1 + memoryLimit    // Wasm pages backing the whole layout
+
+	// memoryLimit is the number of wasm pages the EVM memory is limited to.
+	// Accesses beyond it trap instead of expanding the memory.
+	memoryLimit = 16
+)
+
+// Indices of the EEI host functions imported by every transcompiled contract.
+const (
+	fnStorageStore uint32 = iota
+	fnStorageLoad
+	fnGetCaller
+	fnGetAddress
+	fnGetCallDataSize
+	fnCallDataCopy
+	fnFinish
+	fnRevert
+)
+
+// imports lists the host functions in the order of their indices above.
+var imports = []string{
+	"storageStore",
+	"storageLoad",
+	"getCaller",
+	"getAddress",
+	"getCallDataSize",
+	"callDataCopy",
+	"finish",
+	"revert",
+}
+
+// Indices of the helper functions the transcompiled code calls into. The main
+// function is defined after them.
+const (
+	fnSwapBytes = iota + uint32(fnRevert) + 1
+	fnOffset
+	fnAdd
+	fnSub
+	fnLt
+	fnEq
+	fnIsZero
+	fnSwap
+	fnCallDataLoad
+	fnMain
+
+	// The hashing helpers are only included in contracts using SHA3
+	fnKeccak
+	fnKeccakF
+)
+
+// helper is a function of the transcompiler runtime.
+type helper struct {
+	typ    ewasm.FuncType
+	locals []ewasm.Local
+	body   func(b *builder)
+}
+
+var (
+	pair   = []ewasm.ValueType{ewasm.I32, ewasm.I32}
+	single = []ewasm.ValueType{ewasm.I32}
+)
+
+// helpers lists the runtime functions in the order of their indices above.
+var helpers = []helper{
+	// swapBytes(src, dst) copies a word between memory and a stack slot,
+	// converting between big endian and the stack slot representation.
+	{ewasm.FuncType{Params: pair}, []ewasm.Local{{Count: 1, Type: ewasm.I32}}, func(b *builder) {
+		b.loop()
+		b.get(1).get(2).op(opI32Add)
+		b.get(0).i32(31).op(opI32Add).get(2).op(opI32Sub).mem(opI32Load8U, 0)
+		b.mem(opI32Store8, 0)
+		b.get(2).i32(1).op(opI32Add).tee(2).i32(32).op(opI32LtU).index(ewasm.OpBrIf, 0)
+		b.end()
+	}},
+	// offset(slot) returns the value of a stack slot as a memory offset or
+	// length, trapping if it doesn't fit into 31 bits. This ensures adding it
+	// to the start of the EVM memory doesn't wrap around.
+	{ewasm.FuncType{Params: single, Results: single}, nil, func(b *builder) {
+		b.get(0).mem(opI64Load, 8).get(0).mem(opI64Load, 16).op(opI64Or)
+		b.get(0).mem(opI64Load, 24).op(opI64Or)
+		b.get(0).mem(opI64Load, 0).i64(31).op(opI64ShrU).op(opI64Or)
+		b.op(opI64Eqz, ewasm.OpI32Eqz).when().op(ewasm.OpUnreachable).end()
+		b.get(0).mem(ewasm.OpI32Load, 0)
+	}},
+	// add(a, b) stores a + b into b.
+	{ewasm.FuncType{Params: pair}, []ewasm.Local{{Count: 3, Type: ewasm.I64}}, func(b *builder) {
+		for k := uint32(0); k < 32; k += 8 {
+			b.get(0).mem(opI64Load, k).set(2)
+			b.get(2).get(1).mem(opI64Load, k).op(opI64Add).set(3)
+			b.get(3).get(2).op(opI64LtU, opI64ExtendI32)
+			b.get(3).get(4).op(opI64Add).tee(3).get(4).op(opI64LtU, opI64ExtendI32)
+			b.op(opI64Or).set(4)
+			b.get(1).get(3).mem(opI64Store, k)
+		}
+	}},
+	// sub(a, b) stores a - b into b.
+	{ewasm.FuncType{Params: pair}, []ewasm.Local{{Count: 3, Type: ewasm.I64}}, func(b *builder) {
+		for k := uint32(0); k < 32; k += 8 {
+			b.get(0).mem(opI64Load, k).set(2)
+			b.get(2).get(1).mem(opI64Load, k).op(opI64Sub).set(3)
+			b.get(3).get(2).op(opI64GtU, opI64ExtendI32)
+			b.get(3).get(4).op(opI64LtU, opI64ExtendI32).op(opI64Or)
+			b.get(3).get(4).op(opI64Sub).set(3)
+			b.set(4)
+			b.get(1).get(3).mem(opI64Store, k)
+		}
+	}},
+	// lt(a, b) returns whether a < b.
+	{ewasm.FuncType{Params: pair, Results: single}, nil, func(b *builder) {
+		b.op(ewasm.OpBlock, blockI32)
+		for k := 24; k >= 0; k -= 8 {
+			b.get(0).mem(opI64Load, uint32(k)).get(1).mem(opI64Load, uint32(k)).op(opI64Ne)
+			b.when()
+			b.get(0).mem(opI64Load, uint32(k)).get(1).mem(opI64Load, uint32(k)).op(opI64LtU).br(1)
+			b.end()
+		}
+		b.i32(0)
+		b.end()
+	}},
+	// eq(a, b) returns whether a == b.
+	{ewasm.FuncType{Params: pair, Results: single}, nil, func(b *builder) {
+		for k := uint32(0); k < 32; k += 8 {
+			b.get(0).mem(opI64Load, k).get(1).mem(opI64Load, k).op(opI64Eq)
+			if k > 0 {
+				b.op(opI32And)
+			}
+		}
+	}},
+	// isZero(a) returns whether a == 0.
+	{ewasm.FuncType{Params: single, Results: single}, nil, func(b *builder) {
+		for k := uint32(0); k < 32; k += 8 {
+			b.get(0).mem(opI64Load, k)
+			if k > 0 {
+				b.op(opI64Or)
+			}
+		}
+		b.op(opI64Eqz)
+	}},
+	// swap(a, b) exchanges the contents of two stack slots.
+	{ewasm.FuncType{Params: pair}, []ewasm.Local{{Count: 1, Type: ewasm.I64}}, func(b *builder) {
+		for k := uint32(0); k < 32; k += 8 {
+			b.get(0).mem(opI64Load, k).set(2)
+			b.get(0).get(1).mem(opI64Load, k).mem(opI64Store, k)
+			b.get(1).get(2).mem(opI64Store, k)
+		}
+	}},
+	// callDataLoad(slot) replaces the offset in a stack slot with the word of
+	// call data found there, padding it with zeroes past the end of the data.
+	{ewasm.FuncType{Params: single}, []ewasm.Local{{Count: 2, Type: ewasm.I32}}, func(b *builder) {
+		zeroScratch(b)
+		b.call(fnGetCallDataSize).set(1)
+		b.get(0).mem(opI64Load, 8).get(0).mem(opI64Load, 16).op(opI64Or)
+		b.get(0).mem(opI64Load, 24).op(opI64Or).op(opI64Eqz)
+		b.get(0).mem(opI64Load, 0).get(1).op(opI64ExtendI32, opI64LtU)
+		b.op(opI32And).when()
+		b.get(1).get(0).mem(ewasm.OpI32Load, 0).op(opI32Sub).set(2)
+		b.i32(scratchOffset).get(0).mem(ewasm.OpI32Load, 0)
+		b.get(2).i32(32).get(2).i32(32).op(opI32LtU, ewasm.OpSelect)
+		b.call(fnCallDataCopy)
+		b.end()
+		b.i32(scratchOffset).get(0).call(fnSwapBytes)
+	}},
+}
+
+// zeroScratch clears the first word of the scratch space.
+func zeroScratch(b *builder) {
+	for k := uint32(0); k < 32; k += 8 {
+		b.i32(scratchOffset).i64(0).mem(opI64Store, k)
+	}
+}
+
+// keccakRate is the number of bytes absorbed per permutation by Keccak-256.
+const keccakRate = 136
+
+// keccakHelpers lists the hashing runtime functions in the order of their
+// indices above.
+var keccakHelpers = []helper{
+	// keccak(src, len, dst) hashes len bytes of memory starting at src and
+	// stores the digest into the stack slot dst.
+	{ewasm.FuncType{Params: []ewasm.ValueType{ewasm.I32, ewasm.I32, ewasm.I32}}, []ewasm.Local{{Count: 1, Type: ewasm.I32}}, func(b *builder) {
+		for k := uint32(0); k < 200; k += 8 {
+			b.i32(keccakState).i64(0).mem(opI64Store, k)
+		}
+		// Absorb all full blocks straight from memory
+		b.block().loop()
+		b.get(1).i32(keccakRate).op(opI32LtU).index(ewasm.OpBrIf, 1)
+		absorb(b, func() { b.get(0) })
+		b.get(0).i32(keccakRate).op(opI32Add).set(0)
+		b.get(1).i32(keccakRate).op(opI32Sub).set(1)
+		b.br(0).end().end()
+
+		// Copy the remaining bytes into a padded block and absorb it too
+		for k := uint32(0); k < keccakRate; k += 8 {
+			b.i32(0).i64(0).mem(opI64Store, keccakBlock+k)
+		}
+		b.block().loop()
+		b.get(3).get(1).op(opI32GeU).index(ewasm.OpBrIf, 1)
+		b.get(3).get(0).get(3).op(opI32Add).mem(opI32Load8U, 0).mem(opI32Store8, keccakBlock)
+		b.get(3).i32(1).op(opI32Add).set(3)
+		b.br(0).end().end()
+		b.get(1).i32(0x01).mem(opI32Store8, keccakBlock)
+		b.i32(0).i32(0).mem(opI32Load8U, keccakBlock+keccakRate-1).i32(0x80).op(opI32Or).mem(opI32Store8, keccakBlock+keccakRate-1)
+		absorb(b, func() { b.i32(keccakBlock) })
+
+		// The digest is the big endian word at the start of the state
+		b.i32(keccakState).get(2).call(fnSwapBytes)
+	}},
+	// keccakF(state) applies the keccak-f[1600] permutation to the 25 lanes
+	// of the state, kept in locals 1 to 25 while permuting. Locals 26 to 50
+	// hold the lanes after rho and pi, 51 to 55 the column parities and 57
+	// the offset of the constant of the current round.
+	{ewasm.FuncType{Params: []ewasm.ValueType{ewasm.I32}}, []ewasm.Local{{Count: 56, Type: ewasm.I64}, {Count: 1, Type: ewasm.I32}}, func(b *builder) {
+		const (
+			lanes   = 1
+			rotated = 26
+			columns = 51
+			parity  = 56
+			round   = 57
+		)
+		for i := uint32(0); i < 25; i++ {
+			b.get(0).mem(opI64Load, 8*i).set(lanes + i)
+		}
+		b.loop()
+		// Theta
+		for x := uint32(0); x < 5; x++ {
+			b.get(lanes + x)
+			for y := uint32(1); y < 5; y++ {
+				b.get(lanes + x + 5*y).op(opI64Xor)
+			}
+			b.set(columns + x)
+		}
+		for x := uint32(0); x < 5; x++ {
+			b.get(columns+(x+4)%5).get(columns+(x+1)%5).i64(1).op(opI64Rotl, opI64Xor).set(parity)
+			for y := uint32(0); y < 5; y++ {
+				b.get(lanes + x + 5*y).get(parity).op(opI64Xor).set(lanes + x + 5*y)
+			}
+		}
+		// Rho and pi
+		for x := uint32(0); x < 5; x++ {
+			for y := uint32(0); y < 5; y++ {
+				b.get(lanes + x + 5*y).i64(keccakRotations[x][y]).op(opI64Rotl).set(rotated + y + 5*((2*x+3*y)%5))
+			}
+		}
+		// Chi
+		for x := uint32(0); x < 5; x++ {
+			for y := uint32(0); y < 5; y++ {
+				b.get(rotated + x + 5*y)
+				b.get(rotated + (x+1)%5 + 5*y).i64(-1).op(opI64Xor)
+				b.get(rotated+(x+2)%5+5*y).op(opI64And, opI64Xor).set(lanes + x + 5*y)
+			}
+		}
+		// Iota
+		b.get(lanes).get(round).mem(opI64Load, keccakRounds).op(opI64Xor).set(lanes)
+
+		b.get(round).i32(8).op(opI32Add).tee(round).i32(8*int32(len(keccakRoundConstants))).op(opI32LtU).index(ewasm.OpBrIf, 0)
+		b.end()
+		for i := uint32(0); i < 25; i++ {
+			b.get(0).get(lanes+i).mem(opI64Store, 8*i)
+		}
+	}},
+}
+
+// absorb xors a block of keccakRate bytes, whose address is pushed by the
+// given function, into the state and permutes it.
+func absorb(b *builder, block func()) {
+	for k := uint32(0); k < keccakRate; k += 8 {
+		b.i32(keccakState).i32(keccakState).mem(opI64Load, k)
+		block()
+		b.mem(opI64Load, k).op(opI64Xor).mem(opI64Store, k)
+	}
+	b.i32(keccakState).call(fnKeccakF)
+}
+
+// keccakRotations are the rotation offsets of rho, indexed by lane coordinates.
+var keccakRotations = [5][5]int64{
+	{0, 36, 3, 41, 18},
+	{1, 44, 10, 45, 2},
+	{62, 6, 43, 15, 61},
+	{28, 55, 25, 21, 56},
+	{27, 20, 39, 8, 14},
+}
+
+// keccakRoundConstants are the constants xored into the first lane by iota.
+var keccakRoundConstants = []uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
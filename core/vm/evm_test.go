@@ -0,0 +1,151 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// wasmEchoInterpreter is a custom interpreter running wasm code by returning
+// its input.
+type wasmEchoInterpreter struct {
+	evm *EVM
+}
+
+func (in *wasmEchoInterpreter) Run(contract *Contract, input []byte, static bool) ([]byte, error) {
+	return input, nil
+}
+
+func (in *wasmEchoInterpreter) CanRun(code []byte) bool {
+	return CodeFormat(code) == FormatWasm
+}
+
+// Tests that custom interpreters take precedence over the built-in ones, which
+// still run the code the custom interpreters refuse.
+func TestCustomInterpreter(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+
+	var (
+		wasmAddr = common.BytesToAddress([]byte("wasm"))
+		evmAddr  = common.BytesToAddress([]byte("evm"))
+	)
+	statedb.SetCode(wasmAddr, common.Hex2Bytes("0061736d01000000"))
+	statedb.SetCode(evmAddr, common.Hex2Bytes("602a60005260206000f3")) // return 42
+
+	config := *params.AllEthashProtocolChanges
+	config.EWASMBlock = new(big.Int)
+
+	var created *wasmEchoInterpreter
+	vmConfig := Config{
+		OpcodeCounter: NewOpcodeCounter(),
+		Interpreters: []InterpreterFactory{func(evm *EVM, cfg Config) Interpreter {
+			created = &wasmEchoInterpreter{evm}
+			return created
+		}},
+	}
+	ctx := Context{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: new(big.Int),
+	}
+	evm := NewEVM(ctx, statedb, &config, vmConfig)
+	if created == nil || created.evm != evm {
+		t.Fatalf("custom interpreter not bound to the EVM")
+	}
+	input := []byte("hello")
+	if ret, _, err := evm.Call(AccountRef(common.Address{}), wasmAddr, input, 100000, new(big.Int)); err != nil || !bytes.Equal(ret, input) {
+		t.Errorf("custom interpreter not used: have %x, %v, want %x", ret, err, input)
+	}
+	if ret, _, err := evm.Call(AccountRef(common.Address{}), evmAddr, nil, 100000, new(big.Int)); err != nil || new(big.Int).SetBytes(ret).Uint64() != 42 {
+		t.Errorf("built-in interpreter not used: have %x, %v", ret, err)
+	}
+	if have, want := vmConfig.OpcodeCounter.Formats(), map[string]uint64{"legacy": 1, "wasm": 1}; !reflect.DeepEqual(have, want) {
+		t.Errorf("frame formats mismatch: have %v, want %v", have, want)
+	}
+}
+
+// validationTracer records the verdicts on the validated wasm code.
+type validationTracer struct {
+	*StructLogger
+	hashes     []common.Hash
+	valid      []bool
+	mismatched int // Number of verdicts contradicting their error
+}
+
+func (t *validationTracer) CaptureCodeValidation(codeHash common.Hash, valid bool, err error, d time.Duration) error {
+	if valid != (err == nil) {
+		t.mismatched++
+	}
+	t.hashes = append(t.hashes, codeHash)
+	t.valid = append(t.valid, valid)
+	return nil
+}
+
+// Tests that tracers are told about both accepted and rejected wasm code, with
+// or without debugging, but not about code the sentinel contract never judged.
+func TestCodeValidationTracer(t *testing.T) {
+	for _, debug := range []bool{false, true} {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+
+		config := *params.AllEthashProtocolChanges
+		config.EWASMBlock = new(big.Int)
+
+		tracer := &validationTracer{StructLogger: NewStructLogger(nil)}
+		vmConfig := Config{
+			Debug:  debug,
+			Tracer: tracer,
+			Interpreters: []InterpreterFactory{func(evm *EVM, cfg Config) Interpreter {
+				return &wasmEchoInterpreter{evm}
+			}},
+		}
+		ctx := Context{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: new(big.Int),
+		}
+		evm := NewEVM(ctx, statedb, &config, vmConfig)
+
+		valid := common.Hex2Bytes(sentinelTests[0].input)
+		if _, _, _, err := evm.Create(AccountRef(common.Address{}), valid, 1000000, new(big.Int)); err != nil {
+			t.Fatalf("debug %v: failed to deploy valid module: %v", debug, err)
+		}
+		invalid := common.Hex2Bytes("0061736d0100000001")
+		if _, _, _, err := evm.Create(AccountRef(common.Address{}), invalid, 1000000, new(big.Int)); err == nil {
+			t.Fatalf("debug %v: malformed module deployed", debug)
+		}
+		if _, _, _, err := evm.Create(AccountRef(common.Address{}), invalid, params.SentinelBaseGas-1, new(big.Int)); err != ErrOutOfGas {
+			t.Fatalf("debug %v: underfunded validation error mismatch: have %v, want %v", debug, err, ErrOutOfGas)
+		}
+		wantHashes := []common.Hash{crypto.Keccak256Hash(valid), crypto.Keccak256Hash(invalid)}
+		if !reflect.DeepEqual(tracer.hashes, wantHashes) || !reflect.DeepEqual(tracer.valid, []bool{true, false}) {
+			t.Errorf("debug %v: verdict mismatch: have %x %v, want %x [true false]", debug, tracer.hashes, tracer.valid, wantHashes)
+		}
+		if tracer.mismatched != 0 {
+			t.Errorf("debug %v: %d verdicts contradict their validation error", debug, tracer.mismatched)
+		}
+	}
+}
@@ -20,7 +20,6 @@
 package vm
 
 import (
-	"bytes"
 	"fmt"
 	"math/big"
 	"strings"
@@ -346,8 +345,7 @@ func (evm *EVMC) Run(contract *Contract, input []byte, readOnly bool) (ret []byt
 // CanRun implements Interpreter.CanRun().
 func (evm *EVMC) CanRun(code []byte) bool {
 	required := evmc.CapabilityEVM1
-	wasmPreamble := []byte("\x00asm")
-	if bytes.HasPrefix(code, wasmPreamble) {
+	if codeFormat(evm.env.chainRules, code) == FormatWasm {
 		required = evmc.CapabilityEWASM
 	}
 	return evm.cap == required
@@ -0,0 +1,83 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ewasm
+
+// sig is a shorthand for declaring host function signatures.
+func sig(params []ValueType, results ...ValueType) FuncType {
+	return FuncType{Params: params, Results: results}
+}
+
+func args(types ...ValueType) []ValueType {
+	return types
+}
+
+// EEI lists the host functions of the Ethereum Environment Interface along
+// with their signatures. These are the only imports ewasm contracts may use.
+var EEI = map[string]FuncType{
+	"useGas":              sig(args(I64)),
+	"getAddress":          sig(args(I32)),
+	"getExternalBalance":  sig(args(I32, I32)),
+	"getBlockHash":        sig(args(I64, I32), I32),
+	"call":                sig(args(I64, I32, I32, I32, I32), I32),
+	"callCode":            sig(args(I64, I32, I32, I32, I32), I32),
+	"callDelegate":        sig(args(I64, I32, I32, I32), I32),
+	"callStatic":          sig(args(I64, I32, I32, I32), I32),
+	"callDataCopy":        sig(args(I32, I32, I32)),
+	"getCallDataSize":     sig(nil, I32),
+	"storageStore":        sig(args(I32, I32)),
+	"storageLoad":         sig(args(I32, I32)),
+	"getCaller":           sig(args(I32)),
+	"getCallValue":        sig(args(I32)),
+	"codeCopy":            sig(args(I32, I32, I32)),
+	"getCodeSize":         sig(nil, I32),
+	"getBlockCoinbase":    sig(args(I32)),
+	"create":              sig(args(I32, I32, I32, I32), I32),
+	"getBlockDifficulty":  sig(args(I32)),
+	"externalCodeCopy":    sig(args(I32, I32, I32, I32)),
+	"getExternalCodeSize": sig(args(I32), I32),
+	"getGasLeft":          sig(nil, I64),
+	"getBlockGasLimit":    sig(nil, I64),
+	"getTxGasPrice":       sig(args(I32)),
+	"log":                 sig(args(I32, I32, I32, I32, I32, I32, I32)),
+	"getBlockNumber":      sig(nil, I64),
+	"getTxOrigin":         sig(args(I32)),
+	"finish":              sig(args(I32, I32)),
+	"revert":              sig(args(I32, I32)),
+	"getReturnDataSize":   sig(nil, I32),
+	"returnDataCopy":      sig(args(I32, I32, I32)),
+	"selfDestruct":        sig(args(I32)),
+	"getBlockTimestamp":   sig(nil, I64),
+}
+
+// BignumModule is the namespace of the host functions implementing 256 bit
+// integer arithmetic natively.
+const BignumModule = "bignum"
+
+// Bignum lists the 256 bit arithmetic host functions along with their
+// signatures. Every operand is a pointer to a little endian word in memory.
+var Bignum = map[string]FuncType{
+	"mul256":     sig(args(I32, I32, I32)),
+	"umulmod256": sig(args(I32, I32, I32, I32)),
+	"addmod256":  sig(args(I32, I32, I32, I32)),
+}
+
+// hostModules maps the namespaces ewasm contracts may import from to the host
+// functions available in them.
+var hostModules = map[string]map[string]FuncType{
+	EEIModule:    EEI,
+	BignumModule: Bignum,
+}
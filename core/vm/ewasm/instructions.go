@@ -0,0 +1,134 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ewasm
+
+import "fmt"
+
+// Opcodes of the wasm MVP instruction set that the rewriting passes need to
+// treat specially. All the remaining opcodes are only ever skipped over.
+const (
+	OpUnreachable  byte = 0x00
+	OpNop          byte = 0x01
+	OpBlock        byte = 0x02
+	OpLoop         byte = 0x03
+	OpIf           byte = 0x04
+	OpElse         byte = 0x05
+	OpEnd          byte = 0x0b
+	OpBr           byte = 0x0c
+	OpBrIf         byte = 0x0d
+	OpBrTable      byte = 0x0e
+	OpReturn       byte = 0x0f
+	OpCall         byte = 0x10
+	OpCallIndirect byte = 0x11
+	OpDrop         byte = 0x1a
+	OpSelect       byte = 0x1b
+	OpLocalGet     byte = 0x20
+	OpGlobalSet    byte = 0x24
+	OpI32Load      byte = 0x28
+	OpI64Store32   byte = 0x3e
+	OpMemorySize   byte = 0x3f
+	OpMemoryGrow   byte = 0x40
+	OpI32Const     byte = 0x41
+	OpI64Const     byte = 0x42
+	OpF32Const     byte = 0x43
+	OpF64Const     byte = 0x44
+	OpI32Eqz       byte = 0x45
+	OpI64Extend32S byte = 0xc4
+)
+
+// ErrUnknownOpcode is returned when an instruction stream contains an opcode
+// outside of the supported instruction set.
+type ErrUnknownOpcode byte
+
+func (e ErrUnknownOpcode) Error() string {
+	return fmt.Sprintf("wasm: unknown opcode 0x%02x", byte(e))
+}
+
+// instruction reads a single instruction, returning its opcode and the raw
+// bytes of its immediate arguments.
+func (r *reader) instruction() (byte, []byte, error) {
+	op, err := r.byte()
+	if err != nil {
+		return 0, nil, err
+	}
+	start := r.pos
+
+	switch {
+	case op == OpBlock || op == OpLoop || op == OpIf:
+		_, err = r.sleb(33)
+
+	case op == OpBr || op == OpBrIf || op == OpCall || (op >= OpLocalGet && op <= OpGlobalSet):
+		_, err = r.u32()
+
+	case op == OpBrTable:
+		err = r.vec(func() error {
+			_, err := r.u32()
+			return err
+		})
+		if err == nil {
+			_, err = r.u32()
+		}
+
+	case op == OpCallIndirect:
+		if _, err = r.u32(); err == nil {
+			err = r.reserved()
+		}
+
+	case op >= OpI32Load && op <= OpI64Store32:
+		if _, err = r.u32(); err == nil {
+			_, err = r.u32()
+		}
+
+	case op == OpMemorySize || op == OpMemoryGrow:
+		err = r.reserved()
+
+	case op == OpI32Const:
+		_, err = r.sleb(32)
+
+	case op == OpI64Const:
+		_, err = r.sleb(64)
+
+	case op == OpF32Const:
+		_, err = r.bytes(4)
+
+	case op == OpF64Const:
+		_, err = r.bytes(8)
+
+	case op == OpUnreachable, op == OpNop, op == OpElse, op == OpEnd, op == OpReturn,
+		op == OpDrop, op == OpSelect, op >= OpI32Eqz && op <= OpI64Extend32S:
+		// No immediates
+
+	default:
+		return op, nil, ErrUnknownOpcode(op)
+	}
+	if err != nil {
+		return op, nil, err
+	}
+	return op, r.buf[start:r.pos], nil
+}
+
+// reserved reads a reserved zero byte immediate.
+func (r *reader) reserved() error {
+	b, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if b != 0x00 {
+		return ErrMalformedEncoding
+	}
+	return nil
+}
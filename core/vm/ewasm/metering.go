@@ -0,0 +1,196 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ewasm
+
+import "errors"
+
+// Names of the Ethereum Environment Interface (EEI) namespace and of the host
+// function metered code reports gas consumption through.
+const (
+	EEIModule   = "ethereum"
+	UseGasField = "useGas"
+)
+
+// useGasType is the signature of the useGas host function.
+var useGasType = EEI[UseGasField]
+
+// ErrUseGasSignature is returned if a module already imports useGas with a
+// signature different from the one defined by the EEI.
+var ErrUseGasSignature = errors.New("ewasm: useGas imported with invalid signature")
+
+// CostTable returns the gas charged for executing a single instruction.
+type CostTable func(op byte) uint64
+
+// DefaultCostTable charges a single unit of gas for every instruction.
+func DefaultCostTable(op byte) uint64 {
+	return 1
+}
+
+// InjectMetering decodes a wasm binary, meters it using the default cost table
+// and returns the re-encoded module.
+func InjectMetering(code []byte) ([]byte, error) {
+	m, err := Decode(code)
+	if err != nil {
+		return nil, err
+	}
+	if err := Meter(m, DefaultCostTable); err != nil {
+		return nil, err
+	}
+	return m.Encode(), nil
+}
+
+// Meter rewrites the module in place so that every function charges the gas
+// of each of its basic blocks upon entering it, by calling the useGas host
+// function with the summed up cost of the block's instructions.
+//
+// If the module doesn't import useGas yet, the import is appended to the
+// existing function imports, shifting the indices of all defined functions by
+// one. Every reference to those functions is updated accordingly, with the
+// exception of the debug names custom section, which is dropped.
+func Meter(m *Module, cost CostTable) error {
+	useGas, found, err := m.useGasIndex()
+	if err != nil {
+		return err
+	}
+	if !found {
+		m.addUseGasImport()
+
+		shift := func(index uint32) uint32 {
+			if index >= useGas {
+				return index + 1
+			}
+			return index
+		}
+		for i := range m.Exports {
+			if m.Exports[i].Kind == ExternalFunction {
+				m.Exports[i].Index = shift(m.Exports[i].Index)
+			}
+		}
+		if m.Start != nil {
+			start := shift(*m.Start)
+			m.Start = &start
+		}
+		for i := range m.Elements {
+			for j := range m.Elements[i].Funcs {
+				m.Elements[i].Funcs[j] = shift(m.Elements[i].Funcs[j])
+			}
+		}
+		customs := m.Customs[:0]
+		for _, c := range m.Customs {
+			if c.Name != "name" {
+				customs = append(customs, c)
+			}
+		}
+		m.Customs = customs
+	}
+	for i := range m.Code {
+		code, err := meterCode(m.Code[i].Code, useGas, !found, cost)
+		if err != nil {
+			return err
+		}
+		m.Code[i].Code = code
+	}
+	return nil
+}
+
+// useGasIndex looks up the function index of an existing useGas import. If
+// there is none, the index the import will take once added is returned.
+func (m *Module) useGasIndex() (uint32, bool, error) {
+	var index uint32
+	for _, imp := range m.Imports {
+		if imp.Kind != ExternalFunction {
+			continue
+		}
+		if imp.Module == EEIModule && imp.Field == UseGasField {
+			if uint64(imp.TypeIndex) >= uint64(len(m.Types)) || !m.Types[imp.TypeIndex].Equal(useGasType) {
+				return 0, false, ErrUseGasSignature
+			}
+			return index, true, nil
+		}
+		index++
+	}
+	return index, false, nil
+}
+
+// addUseGasImport appends the useGas import, reusing a matching signature from
+// the type section if there is one.
+func (m *Module) addUseGasImport() {
+	typeIndex := uint32(len(m.Types))
+	for i, ft := range m.Types {
+		if ft.Equal(useGasType) {
+			typeIndex = uint32(i)
+			break
+		}
+	}
+	if typeIndex == uint32(len(m.Types)) {
+		m.Types = append(m.Types, useGasType)
+	}
+	m.Imports = append(m.Imports, Import{
+		Module:    EEIModule,
+		Field:     UseGasField,
+		Kind:      ExternalFunction,
+		TypeIndex: typeIndex,
+	})
+}
+
+// meterCode injects a useGas call at the beginning of every basic block of the
+// given instruction stream. A basic block is ended by any instruction that may
+// transfer control, so that every instruction charged was actually executed.
+// If shifted is set, call targets at or above useGas are incremented by one.
+func meterCode(code []byte, useGas uint32, shifted bool, cost CostTable) ([]byte, error) {
+	var (
+		r       = &reader{buf: code}
+		out     = make([]byte, 0, len(code)+len(code)/4)
+		segment []byte
+		gas     uint64
+	)
+	flush := func() {
+		if gas > 0 {
+			out = append(out, OpI64Const)
+			out = appendSleb(out, int64(gas))
+			out = append(out, OpCall)
+			out = appendU32(out, useGas)
+		}
+		out = append(out, segment...)
+		segment, gas = segment[:0], 0
+	}
+	for !r.done() {
+		start := r.pos
+		op, imm, err := r.instruction()
+		if err != nil {
+			return nil, err
+		}
+		gas += cost(op)
+
+		if op == OpCall && shifted {
+			index, _ := (&reader{buf: imm}).u32()
+			if index >= useGas {
+				index++
+			}
+			segment = append(segment, OpCall)
+			segment = appendU32(segment, index)
+		} else {
+			segment = append(segment, code[start:r.pos]...)
+		}
+		switch op {
+		case OpBlock, OpLoop, OpIf, OpElse, OpEnd, OpBr, OpBrIf, OpBrTable, OpReturn, OpUnreachable:
+			flush()
+		}
+	}
+	flush()
+	return out, nil
+}
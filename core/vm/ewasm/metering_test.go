@@ -0,0 +1,119 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ewasm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestInjectMetering(t *testing.T) {
+	metered, err := InjectMetering(finishContract)
+	if err != nil {
+		t.Fatalf("failed to meter module: %v", err)
+	}
+	m, err := Decode(metered)
+	if err != nil {
+		t.Fatalf("failed to decode metered module: %v", err)
+	}
+	// The useGas import must be appended with a new signature
+	if len(m.Types) != 3 || !m.Types[2].Equal(useGasType) {
+		t.Fatalf("useGas signature missing: %+v", m.Types)
+	}
+	if len(m.Imports) != 2 || m.Imports[1] != (Import{Module: EEIModule, Field: UseGasField, Kind: ExternalFunction, TypeIndex: 2}) {
+		t.Fatalf("useGas import missing: %+v", m.Imports)
+	}
+	// The main function got shifted to make room for the import
+	if m.Exports[1].Name != "main" || m.Exports[1].Index != 2 {
+		t.Errorf("main export not shifted: %+v", m.Exports[1])
+	}
+	if m.Exports[0].Name != "memory" || m.Exports[0].Index != 0 {
+		t.Errorf("memory export modified: %+v", m.Exports[0])
+	}
+	// i64.const 4, call $useGas, followed by the original body
+	want := common.FromHex("0x42041001" + "410041001000" + "0b")
+	if !bytes.Equal(m.Code[0].Code, want) {
+		t.Errorf("metered body mismatch:\nhave %x\nwant %x", m.Code[0].Code, want)
+	}
+}
+
+func TestInjectMeteringExistingImport(t *testing.T) {
+	m, err := Decode(finishContract)
+	if err != nil {
+		t.Fatalf("failed to decode module: %v", err)
+	}
+	m.Types = append(m.Types, useGasType)
+	m.Imports = append(m.Imports, Import{Module: EEIModule, Field: UseGasField, Kind: ExternalFunction, TypeIndex: 2})
+	m.Exports[1].Index = 2
+
+	metered, err := InjectMetering(m.Encode())
+	if err != nil {
+		t.Fatalf("failed to meter module: %v", err)
+	}
+	if m, err = Decode(metered); err != nil {
+		t.Fatalf("failed to decode metered module: %v", err)
+	}
+	if len(m.Types) != 3 || len(m.Imports) != 2 {
+		t.Fatalf("existing useGas import not reused: %+v %+v", m.Types, m.Imports)
+	}
+	if m.Exports[1].Index != 2 {
+		t.Errorf("main export modified: %+v", m.Exports[1])
+	}
+	want := common.FromHex("0x42041001" + "410041001000" + "0b")
+	if !bytes.Equal(m.Code[0].Code, want) {
+		t.Errorf("metered body mismatch:\nhave %x\nwant %x", m.Code[0].Code, want)
+	}
+	// Importing useGas with the wrong signature must be rejected
+	m.Imports[1].TypeIndex = 0
+	if _, err := InjectMetering(m.Encode()); err != ErrUseGasSignature {
+		t.Errorf("invalid useGas signature: have %v, want %v", err, ErrUseGasSignature)
+	}
+}
+
+func TestMeterBasicBlocks(t *testing.T) {
+	tests := []struct {
+		code    string
+		shifted bool
+		want    string
+	}{
+		// Straight line code is charged once upfront
+		{"0x01010b", false, "0x42031005" + "01010b"},
+		// Loops charge on every iteration from within their body
+		{"0x0340" + "01" + "0c00" + "0b" + "0b", false,
+			"0x42011005" + "0340" + "42021005" + "01" + "0c00" + "42011005" + "0b" + "42011005" + "0b"},
+		// Both branches of a conditional are charged separately
+		{"0x410004400105010b0b", false,
+			"0x42021005" + "41000440" + "42021005" + "0105" + "42021005" + "010b" + "42011005" + "0b"},
+		// Calls into defined functions are shifted, imported ones are not
+		{"0x100410050b", true, "0x42031005" + "10041006" + "0b"},
+	}
+	for i, tt := range tests {
+		have, err := meterCode(common.FromHex(tt.code), 5, tt.shifted, DefaultCostTable)
+		if err != nil {
+			t.Errorf("test %d: failed to meter code: %v", i, err)
+			continue
+		}
+		if want := common.FromHex(tt.want); !bytes.Equal(have, want) {
+			t.Errorf("test %d: metered code mismatch:\nhave %x\nwant %x", i, have, want)
+		}
+	}
+	if _, err := meterCode([]byte{0xfc, 0x00, 0x0b}, 0, false, DefaultCostTable); err != ErrUnknownOpcode(0xfc) {
+		t.Errorf("unknown opcode: have %v, want %v", err, ErrUnknownOpcode(0xfc))
+	}
+}
@@ -0,0 +1,742 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package ewasm implements the deploy-time processing of ewasm contracts: a
+// decoder and encoder for the wasm binary format and the rewriting passes the
+// ewasm design applies to contract code before it is stored in the state.
+package ewasm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Magic is the preamble every wasm binary starts with.
+var Magic = []byte("\x00asm")
+
+// Version is the only wasm binary format version supported.
+const Version = 1
+
+// IsWasm reports whether the given code carries the wasm preamble.
+func IsWasm(code []byte) bool {
+	return bytes.HasPrefix(code, Magic)
+}
+
+// Section identifiers of the wasm binary format.
+const (
+	SectionCustom   byte = 0
+	SectionType     byte = 1
+	SectionImport   byte = 2
+	SectionFunction byte = 3
+	SectionTable    byte = 4
+	SectionMemory   byte = 5
+	SectionGlobal   byte = 6
+	SectionExport   byte = 7
+	SectionStart    byte = 8
+	SectionElement  byte = 9
+	SectionCode     byte = 10
+	SectionData     byte = 11
+)
+
+// ValueType is a wasm value type.
+type ValueType byte
+
+// Value types of the wasm MVP.
+const (
+	I32 ValueType = 0x7f
+	I64 ValueType = 0x7e
+	F32 ValueType = 0x7d
+	F64 ValueType = 0x7c
+)
+
+func (t ValueType) String() string {
+	switch t {
+	case I32:
+		return "i32"
+	case I64:
+		return "i64"
+	case F32:
+		return "f32"
+	case F64:
+		return "f64"
+	}
+	return fmt.Sprintf("valtype(0x%02x)", byte(t))
+}
+
+// ExternalKind identifies the kind of an import or export.
+type ExternalKind byte
+
+// External kinds of the wasm MVP.
+const (
+	ExternalFunction ExternalKind = 0
+	ExternalTable    ExternalKind = 1
+	ExternalMemory   ExternalKind = 2
+	ExternalGlobal   ExternalKind = 3
+)
+
+// funcTypeForm is the leading byte of every function signature.
+const funcTypeForm = 0x60
+
+// elemTypeAnyFunc is the only table element type of the wasm MVP.
+const elemTypeAnyFunc = 0x70
+
+// FuncType is a function signature.
+type FuncType struct {
+	Params  []ValueType
+	Results []ValueType
+}
+
+// Equal reports whether two signatures are identical.
+func (f FuncType) Equal(other FuncType) bool {
+	return bytes.Equal(valueTypeBytes(f.Params), valueTypeBytes(other.Params)) &&
+		bytes.Equal(valueTypeBytes(f.Results), valueTypeBytes(other.Results))
+}
+
+// Limits is the size range of a memory or table.
+type Limits struct {
+	Min    uint32
+	Max    uint32
+	HasMax bool
+}
+
+// Table is a table definition.
+type Table struct {
+	ElemType byte
+	Limits   Limits
+}
+
+// GlobalType is the type of a global variable.
+type GlobalType struct {
+	Type    ValueType
+	Mutable bool
+}
+
+// Import is an entry of the import section. Depending on Kind, only one of
+// TypeIndex, Table, Memory and Global is meaningful.
+type Import struct {
+	Module string
+	Field  string
+	Kind   ExternalKind
+
+	TypeIndex uint32
+	Table     Table
+	Memory    Limits
+	Global    GlobalType
+}
+
+// Global is a global variable definition with its initializer expression
+// (including the terminating end instruction).
+type Global struct {
+	Type GlobalType
+	Init []byte
+}
+
+// Export is an entry of the export section.
+type Export struct {
+	Name  string
+	Kind  ExternalKind
+	Index uint32
+}
+
+// Element is a table initializer segment.
+type Element struct {
+	Table  uint32
+	Offset []byte
+	Funcs  []uint32
+}
+
+// Local is a run-length encoded group of function locals.
+type Local struct {
+	Count uint32
+	Type  ValueType
+}
+
+// Body is the body of a defined function. Code holds the raw instruction
+// stream, including the terminating end instruction.
+type Body struct {
+	Locals []Local
+	Code   []byte
+}
+
+// Data is a memory initializer segment.
+type Data struct {
+	Memory uint32
+	Offset []byte
+	Init   []byte
+}
+
+// Custom is a custom section, kept verbatim along with the identifier of the
+// known section it followed so it can be put back in place on encoding.
+type Custom struct {
+	Name    string
+	Payload []byte
+	After   byte
+}
+
+// Module is a decoded wasm module.
+type Module struct {
+	Types     []FuncType
+	Imports   []Import
+	Functions []uint32 // Type indices of the defined functions
+	Tables    []Table
+	Memories  []Limits
+	Globals   []Global
+	Exports   []Export
+	Start     *uint32
+	Elements  []Element
+	Code      []Body
+	Data      []Data
+	Customs   []Custom
+}
+
+var (
+	ErrInvalidMagic      = errors.New("wasm: invalid magic")
+	ErrInvalidVersion    = errors.New("wasm: unsupported version")
+	ErrUnexpectedEOF     = errors.New("wasm: unexpected end of input")
+	ErrSectionOrder      = errors.New("wasm: section out of order")
+	ErrSectionSize       = errors.New("wasm: section size mismatch")
+	ErrIntegerOverflow   = errors.New("wasm: integer representation too long")
+	ErrFunctionMismatch  = errors.New("wasm: function and code section have inconsistent lengths")
+	ErrInvalidUTF8       = errors.New("wasm: invalid utf-8 name")
+	ErrMalformedEncoding = errors.New("wasm: malformed encoding")
+)
+
+// NumImportedFuncs returns the number of function imports, which occupy the
+// lowest indices of the function index space.
+func (m *Module) NumImportedFuncs() uint32 {
+	var n uint32
+	for _, imp := range m.Imports {
+		if imp.Kind == ExternalFunction {
+			n++
+		}
+	}
+	return n
+}
+
+// FuncType returns the signature of the function at the given index of the
+// function index space, or nil if the index is out of bounds.
+func (m *Module) FuncType(index uint32) *FuncType {
+	var typeIndex uint32
+	imported := m.NumImportedFuncs()
+	if index < imported {
+		var n uint32
+		for _, imp := range m.Imports {
+			if imp.Kind != ExternalFunction {
+				continue
+			}
+			if n == index {
+				typeIndex = imp.TypeIndex
+				break
+			}
+			n++
+		}
+	} else {
+		if uint64(index-imported) >= uint64(len(m.Functions)) {
+			return nil
+		}
+		typeIndex = m.Functions[index-imported]
+	}
+	if uint64(typeIndex) >= uint64(len(m.Types)) {
+		return nil
+	}
+	return &m.Types[typeIndex]
+}
+
+// Decode parses a wasm binary into a module. Only the structure of the binary
+// is checked; instruction streams are kept verbatim.
+func Decode(code []byte) (*Module, error) {
+	if !IsWasm(code) {
+		return nil, ErrInvalidMagic
+	}
+	if len(code) < 8 {
+		return nil, ErrUnexpectedEOF
+	}
+	if binary.LittleEndian.Uint32(code[4:8]) != Version {
+		return nil, ErrInvalidVersion
+	}
+	var (
+		m    = new(Module)
+		r    = &reader{buf: code, pos: 8}
+		last byte
+	)
+	for !r.done() {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := r.bytes(size)
+		if err != nil {
+			return nil, err
+		}
+		if id != SectionCustom {
+			if id > SectionData || id <= last {
+				return nil, ErrSectionOrder
+			}
+			last = id
+		}
+		sr := &reader{buf: payload}
+		if err := m.decodeSection(id, last, sr); err != nil {
+			return nil, err
+		}
+		if !sr.done() {
+			return nil, ErrSectionSize
+		}
+	}
+	if len(m.Functions) != len(m.Code) {
+		return nil, ErrFunctionMismatch
+	}
+	return m, nil
+}
+
+func (m *Module) decodeSection(id, last byte, r *reader) error {
+	var err error
+	switch id {
+	case SectionCustom:
+		c := Custom{After: last}
+		if c.Name, err = r.name(); err != nil {
+			return err
+		}
+		c.Payload = r.rest()
+		m.Customs = append(m.Customs, c)
+
+	case SectionType:
+		return r.vec(func() error {
+			form, err := r.byte()
+			if err != nil {
+				return err
+			}
+			if form != funcTypeForm {
+				return ErrMalformedEncoding
+			}
+			var ft FuncType
+			if ft.Params, err = r.valueTypes(); err != nil {
+				return err
+			}
+			if ft.Results, err = r.valueTypes(); err != nil {
+				return err
+			}
+			m.Types = append(m.Types, ft)
+			return nil
+		})
+
+	case SectionImport:
+		return r.vec(func() error {
+			var (
+				imp Import
+				err error
+			)
+			if imp.Module, err = r.name(); err != nil {
+				return err
+			}
+			if imp.Field, err = r.name(); err != nil {
+				return err
+			}
+			kind, err := r.byte()
+			if err != nil {
+				return err
+			}
+			imp.Kind = ExternalKind(kind)
+			switch imp.Kind {
+			case ExternalFunction:
+				imp.TypeIndex, err = r.u32()
+			case ExternalTable:
+				imp.Table, err = r.table()
+			case ExternalMemory:
+				imp.Memory, err = r.limits()
+			case ExternalGlobal:
+				imp.Global, err = r.globalType()
+			default:
+				err = ErrMalformedEncoding
+			}
+			if err != nil {
+				return err
+			}
+			m.Imports = append(m.Imports, imp)
+			return nil
+		})
+
+	case SectionFunction:
+		return r.vec(func() error {
+			index, err := r.u32()
+			if err != nil {
+				return err
+			}
+			m.Functions = append(m.Functions, index)
+			return nil
+		})
+
+	case SectionTable:
+		return r.vec(func() error {
+			table, err := r.table()
+			if err != nil {
+				return err
+			}
+			m.Tables = append(m.Tables, table)
+			return nil
+		})
+
+	case SectionMemory:
+		return r.vec(func() error {
+			limits, err := r.limits()
+			if err != nil {
+				return err
+			}
+			m.Memories = append(m.Memories, limits)
+			return nil
+		})
+
+	case SectionGlobal:
+		return r.vec(func() error {
+			var (
+				global Global
+				err    error
+			)
+			if global.Type, err = r.globalType(); err != nil {
+				return err
+			}
+			if global.Init, err = r.expr(); err != nil {
+				return err
+			}
+			m.Globals = append(m.Globals, global)
+			return nil
+		})
+
+	case SectionExport:
+		return r.vec(func() error {
+			var (
+				exp Export
+				err error
+			)
+			if exp.Name, err = r.name(); err != nil {
+				return err
+			}
+			kind, err := r.byte()
+			if err != nil {
+				return err
+			}
+			if kind > byte(ExternalGlobal) {
+				return ErrMalformedEncoding
+			}
+			exp.Kind = ExternalKind(kind)
+			if exp.Index, err = r.u32(); err != nil {
+				return err
+			}
+			m.Exports = append(m.Exports, exp)
+			return nil
+		})
+
+	case SectionStart:
+		index, err := r.u32()
+		if err != nil {
+			return err
+		}
+		m.Start = &index
+
+	case SectionElement:
+		return r.vec(func() error {
+			var (
+				elem Element
+				err  error
+			)
+			if elem.Table, err = r.u32(); err != nil {
+				return err
+			}
+			if elem.Offset, err = r.expr(); err != nil {
+				return err
+			}
+			err = r.vec(func() error {
+				index, err := r.u32()
+				if err != nil {
+					return err
+				}
+				elem.Funcs = append(elem.Funcs, index)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			m.Elements = append(m.Elements, elem)
+			return nil
+		})
+
+	case SectionCode:
+		return r.vec(func() error {
+			size, err := r.u32()
+			if err != nil {
+				return err
+			}
+			raw, err := r.bytes(size)
+			if err != nil {
+				return err
+			}
+			var (
+				body Body
+				br   = &reader{buf: raw}
+			)
+			err = br.vec(func() error {
+				var (
+					local Local
+					err   error
+				)
+				if local.Count, err = br.u32(); err != nil {
+					return err
+				}
+				if local.Type, err = br.valueType(); err != nil {
+					return err
+				}
+				body.Locals = append(body.Locals, local)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if body.Code, err = br.expr(); err != nil {
+				return err
+			}
+			if !br.done() {
+				return ErrSectionSize
+			}
+			m.Code = append(m.Code, body)
+			return nil
+		})
+
+	case SectionData:
+		return r.vec(func() error {
+			var (
+				data Data
+				err  error
+			)
+			if data.Memory, err = r.u32(); err != nil {
+				return err
+			}
+			if data.Offset, err = r.expr(); err != nil {
+				return err
+			}
+			size, err := r.u32()
+			if err != nil {
+				return err
+			}
+			if data.Init, err = r.bytes(size); err != nil {
+				return err
+			}
+			m.Data = append(m.Data, data)
+			return nil
+		})
+	}
+	return nil
+}
+
+// Encode serializes the module into the wasm binary format. Empty sections
+// are omitted.
+func (m *Module) Encode() []byte {
+	out := make([]byte, 8)
+	copy(out, Magic)
+	binary.LittleEndian.PutUint32(out[4:], Version)
+
+	out = m.appendCustoms(out, SectionCustom)
+	for id := SectionType; id <= SectionData; id++ {
+		if payload := m.encodeSection(id); payload != nil {
+			out = appendSection(out, id, payload)
+		}
+		out = m.appendCustoms(out, id)
+	}
+	return out
+}
+
+func (m *Module) appendCustoms(out []byte, after byte) []byte {
+	for _, c := range m.Customs {
+		if c.After == after {
+			payload := appendName(nil, c.Name)
+			out = appendSection(out, SectionCustom, append(payload, c.Payload...))
+		}
+	}
+	return out
+}
+
+func (m *Module) encodeSection(id byte) []byte {
+	var out []byte
+	switch id {
+	case SectionType:
+		if len(m.Types) == 0 {
+			return nil
+		}
+		out = appendU32(out, uint32(len(m.Types)))
+		for _, ft := range m.Types {
+			out = append(out, funcTypeForm)
+			out = appendU32(out, uint32(len(ft.Params)))
+			out = append(out, valueTypeBytes(ft.Params)...)
+			out = appendU32(out, uint32(len(ft.Results)))
+			out = append(out, valueTypeBytes(ft.Results)...)
+		}
+	case SectionImport:
+		if len(m.Imports) == 0 {
+			return nil
+		}
+		out = appendU32(out, uint32(len(m.Imports)))
+		for _, imp := range m.Imports {
+			out = appendName(out, imp.Module)
+			out = appendName(out, imp.Field)
+			out = append(out, byte(imp.Kind))
+			switch imp.Kind {
+			case ExternalFunction:
+				out = appendU32(out, imp.TypeIndex)
+			case ExternalTable:
+				out = appendTable(out, imp.Table)
+			case ExternalMemory:
+				out = appendLimits(out, imp.Memory)
+			case ExternalGlobal:
+				out = appendGlobalType(out, imp.Global)
+			}
+		}
+	case SectionFunction:
+		if len(m.Functions) == 0 {
+			return nil
+		}
+		out = appendU32(out, uint32(len(m.Functions)))
+		for _, index := range m.Functions {
+			out = appendU32(out, index)
+		}
+	case SectionTable:
+		if len(m.Tables) == 0 {
+			return nil
+		}
+		out = appendU32(out, uint32(len(m.Tables)))
+		for _, table := range m.Tables {
+			out = appendTable(out, table)
+		}
+	case SectionMemory:
+		if len(m.Memories) == 0 {
+			return nil
+		}
+		out = appendU32(out, uint32(len(m.Memories)))
+		for _, limits := range m.Memories {
+			out = appendLimits(out, limits)
+		}
+	case SectionGlobal:
+		if len(m.Globals) == 0 {
+			return nil
+		}
+		out = appendU32(out, uint32(len(m.Globals)))
+		for _, global := range m.Globals {
+			out = appendGlobalType(out, global.Type)
+			out = append(out, global.Init...)
+		}
+	case SectionExport:
+		if len(m.Exports) == 0 {
+			return nil
+		}
+		out = appendU32(out, uint32(len(m.Exports)))
+		for _, exp := range m.Exports {
+			out = appendName(out, exp.Name)
+			out = append(out, byte(exp.Kind))
+			out = appendU32(out, exp.Index)
+		}
+	case SectionStart:
+		if m.Start == nil {
+			return nil
+		}
+		out = appendU32(out, *m.Start)
+	case SectionElement:
+		if len(m.Elements) == 0 {
+			return nil
+		}
+		out = appendU32(out, uint32(len(m.Elements)))
+		for _, elem := range m.Elements {
+			out = appendU32(out, elem.Table)
+			out = append(out, elem.Offset...)
+			out = appendU32(out, uint32(len(elem.Funcs)))
+			for _, index := range elem.Funcs {
+				out = appendU32(out, index)
+			}
+		}
+	case SectionCode:
+		if len(m.Code) == 0 {
+			return nil
+		}
+		out = appendU32(out, uint32(len(m.Code)))
+		for _, body := range m.Code {
+			raw := appendU32(nil, uint32(len(body.Locals)))
+			for _, local := range body.Locals {
+				raw = appendU32(raw, local.Count)
+				raw = append(raw, byte(local.Type))
+			}
+			raw = append(raw, body.Code...)
+
+			out = appendU32(out, uint32(len(raw)))
+			out = append(out, raw...)
+		}
+	case SectionData:
+		if len(m.Data) == 0 {
+			return nil
+		}
+		out = appendU32(out, uint32(len(m.Data)))
+		for _, data := range m.Data {
+			out = appendU32(out, data.Memory)
+			out = append(out, data.Offset...)
+			out = appendU32(out, uint32(len(data.Init)))
+			out = append(out, data.Init...)
+		}
+	}
+	return out
+}
+
+func valueTypeBytes(types []ValueType) []byte {
+	out := make([]byte, len(types))
+	for i, t := range types {
+		out[i] = byte(t)
+	}
+	return out
+}
+
+func appendSection(out []byte, id byte, payload []byte) []byte {
+	out = append(out, id)
+	out = appendU32(out, uint32(len(payload)))
+	return append(out, payload...)
+}
+
+func appendName(out []byte, name string) []byte {
+	out = appendU32(out, uint32(len(name)))
+	return append(out, name...)
+}
+
+func appendLimits(out []byte, limits Limits) []byte {
+	if limits.HasMax {
+		out = append(out, 0x01)
+		out = appendU32(out, limits.Min)
+		return appendU32(out, limits.Max)
+	}
+	out = append(out, 0x00)
+	return appendU32(out, limits.Min)
+}
+
+func appendTable(out []byte, table Table) []byte {
+	out = append(out, table.ElemType)
+	return appendLimits(out, table.Limits)
+}
+
+func appendGlobalType(out []byte, global GlobalType) []byte {
+	out = append(out, byte(global.Type))
+	if global.Mutable {
+		return append(out, 0x01)
+	}
+	return append(out, 0x00)
+}
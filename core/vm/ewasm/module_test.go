@@ -0,0 +1,134 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ewasm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// finishContract is a minimal ewasm contract importing ethereum.finish and
+// exporting its memory and main function:
+//
+//   (module
+//     (import "ethereum" "finish" (func $finish (param i32 i32)))
+//     (memory 1)
+//     (export "memory" (memory 0))
+//     (export "main" (func $main))
+//     (func $main
+//       (call $finish (i32.const 0) (i32.const 0))))
+var finishContract = common.FromHex("0x0061736d01000000" +
+	"01090260027f7f00600000" + // type section: (i32 i32)->(), ()->()
+	"02130108657468657265756d0666696e6973680000" + // import section: ethereum.finish
+	"03020101" + // function section: main
+	"0503010001" + // memory section: min 1
+	"071102066d656d6f72790200046d61696e0001" + // export section: memory, main
+	"0a0a010800410041001000" + "0b") // code section: main
+
+func TestDecodeEncodeRoundtrip(t *testing.T) {
+	m, err := Decode(finishContract)
+	if err != nil {
+		t.Fatalf("failed to decode module: %v", err)
+	}
+	if len(m.Types) != 2 || len(m.Imports) != 1 || len(m.Functions) != 1 || len(m.Memories) != 1 || len(m.Exports) != 2 || len(m.Code) != 1 {
+		t.Fatalf("unexpected module layout: %+v", m)
+	}
+	if imp := m.Imports[0]; imp.Module != "ethereum" || imp.Field != "finish" || imp.Kind != ExternalFunction {
+		t.Errorf("unexpected import: %+v", imp)
+	}
+	if ft := m.FuncType(1); ft == nil || len(ft.Params) != 0 || len(ft.Results) != 0 {
+		t.Errorf("unexpected main signature: %+v", ft)
+	}
+	if ft := m.FuncType(2); ft != nil {
+		t.Errorf("out of bounds function has signature: %+v", ft)
+	}
+	if enc := m.Encode(); !bytes.Equal(enc, finishContract) {
+		t.Errorf("roundtrip mismatch:\nhave %x\nwant %x", enc, finishContract)
+	}
+}
+
+func TestDecodeCustomSections(t *testing.T) {
+	code := append(common.CopyBytes(finishContract), common.FromHex("0x000604746573742a")...)
+
+	m, err := Decode(code)
+	if err != nil {
+		t.Fatalf("failed to decode module: %v", err)
+	}
+	if len(m.Customs) != 1 || m.Customs[0].Name != "test" || m.Customs[0].After != SectionCode {
+		t.Fatalf("unexpected custom sections: %+v", m.Customs)
+	}
+	if enc := m.Encode(); !bytes.Equal(enc, code) {
+		t.Errorf("roundtrip mismatch:\nhave %x\nwant %x", enc, code)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		err  error
+	}{
+		{"0x", ErrInvalidMagic},
+		{"0x0061736e01000000", ErrInvalidMagic},
+		{"0x0061736d010000", ErrUnexpectedEOF},
+		{"0x0061736d02000000", ErrInvalidVersion},
+		{"0x0061736d010000000105", ErrUnexpectedEOF},
+		{"0x0061736d01000000" + "030100" + "010100", ErrSectionOrder},
+		{"0x0061736d01000000" + "05020100", ErrUnexpectedEOF},
+		{"0x0061736d01000000" + "0504010001ff", ErrSectionSize},
+		{"0x0061736d01000000" + "010401600000", nil},
+		{"0x0061736d01000000" + "01040160000003020100", ErrFunctionMismatch},
+		{"0x0061736d01000000" + "010401610000", ErrMalformedEncoding},
+	}
+	for i, tt := range tests {
+		_, err := Decode(common.FromHex(tt.code))
+		if tt.err == nil {
+			if err != nil {
+				t.Errorf("test %d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+		if err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestLEB128(t *testing.T) {
+	for _, v := range []int64{0, 1, -1, 63, 64, -64, -65, 127, 128, 1 << 31, -1 << 31, 1<<63 - 1, -1 << 63} {
+		enc := appendSleb(nil, v)
+		dec, err := (&reader{buf: enc}).sleb(64)
+		if err != nil || dec != v {
+			t.Errorf("signed %d: have %d (%v), encoding %x", v, dec, err, enc)
+		}
+	}
+	for _, v := range []uint64{0, 1, 127, 128, 1<<32 - 1, 1<<64 - 1} {
+		enc := appendUleb(nil, v)
+		dec, err := (&reader{buf: enc}).uleb(64)
+		if err != nil || dec != v {
+			t.Errorf("unsigned %d: have %d (%v), encoding %x", v, dec, err, enc)
+		}
+	}
+	// Integers not fitting into their declared width must be rejected
+	if _, err := (&reader{buf: appendUleb(nil, 1<<32)}).u32(); err != ErrIntegerOverflow {
+		t.Errorf("oversized u32: have %v, want %v", err, ErrIntegerOverflow)
+	}
+	if _, err := (&reader{buf: appendSleb(nil, 1<<31)}).sleb(32); err != ErrIntegerOverflow {
+		t.Errorf("oversized s32: have %v, want %v", err, ErrIntegerOverflow)
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ewasm
+
+import "unicode/utf8"
+
+// reader is a cursor over a wasm binary, decoding the primitive encodings of
+// the format (LEB128 integers, names, vectors).
+type reader struct {
+	buf []byte
+	pos int
+}
+
+func (r *reader) done() bool {
+	return r.pos >= len(r.buf)
+}
+
+func (r *reader) rest() []byte {
+	rest := r.buf[r.pos:]
+	r.pos = len(r.buf)
+	return rest
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, ErrUnexpectedEOF
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n uint32) ([]byte, error) {
+	if uint64(len(r.buf)-r.pos) < uint64(n) {
+		return nil, ErrUnexpectedEOF
+	}
+	b := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// uleb reads an unsigned LEB128 integer of at most the given bit width.
+func (r *reader) uleb(bits uint) (uint64, error) {
+	var (
+		result uint64
+		shift  uint
+	)
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		if shift+7 > bits && b>>(bits-shift) != 0 {
+			return 0, ErrIntegerOverflow
+		}
+		result |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+		shift += 7
+		if shift >= bits {
+			return 0, ErrIntegerOverflow
+		}
+	}
+}
+
+// sleb reads a signed LEB128 integer of at most the given bit width.
+func (r *reader) sleb(bits uint) (int64, error) {
+	var (
+		result int64
+		shift  uint
+		b      byte
+		err    error
+	)
+	for {
+		if b, err = r.byte(); err != nil {
+			return 0, err
+		}
+		// The last byte of a 64 bit integer may only carry the sign bit
+		if shift == 63 && b != 0x00 && b != 0x7f {
+			return 0, ErrIntegerOverflow
+		}
+		result |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			break
+		}
+		if shift >= bits {
+			return 0, ErrIntegerOverflow
+		}
+	}
+	if shift < 64 && b&0x40 != 0 {
+		result |= -1 << shift
+	}
+	if bits < 64 {
+		if min, max := int64(-1)<<(bits-1), int64(1)<<(bits-1)-1; result < min || result > max {
+			return 0, ErrIntegerOverflow
+		}
+	}
+	return result, nil
+}
+
+func (r *reader) u32() (uint32, error) {
+	v, err := r.uleb(32)
+	return uint32(v), err
+}
+
+func (r *reader) name() (string, error) {
+	size, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.bytes(size)
+	if err != nil {
+		return "", err
+	}
+	if !utf8.Valid(b) {
+		return "", ErrInvalidUTF8
+	}
+	return string(b), nil
+}
+
+// vec reads the length prefix of a vector and invokes fn for every element.
+func (r *reader) vec(fn func() error) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	// Every element takes at least one byte, reject bogus lengths before
+	// looping on them.
+	if uint64(n) > uint64(len(r.buf)-r.pos) {
+		return ErrUnexpectedEOF
+	}
+	for i := uint32(0); i < n; i++ {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *reader) valueType() (ValueType, error) {
+	b, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch t := ValueType(b); t {
+	case I32, I64, F32, F64:
+		return t, nil
+	}
+	return 0, ErrMalformedEncoding
+}
+
+func (r *reader) valueTypes() ([]ValueType, error) {
+	var types []ValueType
+	err := r.vec(func() error {
+		t, err := r.valueType()
+		if err != nil {
+			return err
+		}
+		types = append(types, t)
+		return nil
+	})
+	return types, err
+}
+
+func (r *reader) limits() (Limits, error) {
+	var limits Limits
+
+	flag, err := r.byte()
+	if err != nil {
+		return limits, err
+	}
+	if flag > 0x01 {
+		return limits, ErrMalformedEncoding
+	}
+	if limits.Min, err = r.u32(); err != nil {
+		return limits, err
+	}
+	if flag == 0x01 {
+		limits.HasMax = true
+		if limits.Max, err = r.u32(); err != nil {
+			return limits, err
+		}
+	}
+	return limits, nil
+}
+
+func (r *reader) table() (Table, error) {
+	var (
+		table Table
+		err   error
+	)
+	if table.ElemType, err = r.byte(); err != nil {
+		return table, err
+	}
+	if table.ElemType != elemTypeAnyFunc {
+		return table, ErrMalformedEncoding
+	}
+	table.Limits, err = r.limits()
+	return table, err
+}
+
+func (r *reader) globalType() (GlobalType, error) {
+	var (
+		global GlobalType
+		err    error
+	)
+	if global.Type, err = r.valueType(); err != nil {
+		return global, err
+	}
+	mut, err := r.byte()
+	if err != nil {
+		return global, err
+	}
+	if mut > 0x01 {
+		return global, ErrMalformedEncoding
+	}
+	global.Mutable = mut == 0x01
+	return global, nil
+}
+
+// expr reads an instruction sequence up to and including the end instruction
+// closing it, returning the raw bytes.
+func (r *reader) expr() ([]byte, error) {
+	var (
+		start = r.pos
+		depth = 1
+	)
+	for depth > 0 {
+		op, _, err := r.instruction()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case OpBlock, OpLoop, OpIf:
+			depth++
+		case OpEnd:
+			depth--
+		}
+	}
+	return r.buf[start:r.pos], nil
+}
+
+// appendU32 appends the unsigned LEB128 encoding of v.
+func appendU32(out []byte, v uint32) []byte {
+	return appendUleb(out, uint64(v))
+}
+
+func appendUleb(out []byte, v uint64) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+// appendSleb appends the signed LEB128 encoding of v.
+func appendSleb(out []byte, v int64) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
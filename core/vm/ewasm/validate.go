@@ -0,0 +1,246 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ewasm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxTableSize is the maximum number of elements a table of an ewasm contract
+// may be declared with.
+const MaxTableSize = 8192
+
+// Names of the exports every ewasm contract has to provide.
+const (
+	MainExport   = "main"
+	MemoryExport = "memory"
+)
+
+var (
+	ErrFloatingPoint   = errors.New("ewasm: floating point is not allowed")
+	ErrMemoryCount     = errors.New("ewasm: module must define exactly one memory")
+	ErrUnboundedTable  = errors.New("ewasm: table must declare a maximum size")
+	ErrTableTooLarge   = errors.New("ewasm: table exceeds maximum size")
+	ErrStartFunction   = errors.New("ewasm: start function is not allowed")
+	ErrInvalidExports  = errors.New("ewasm: module must export exactly main and memory")
+	ErrMainSignature   = errors.New("ewasm: main must take no arguments and return nothing")
+	ErrInvalidTypeRef  = errors.New("ewasm: reference to undefined type")
+	ErrInvalidFuncRef  = errors.New("ewasm: reference to undefined function")
+	ErrInvalidTableRef = errors.New("ewasm: reference to undefined table")
+)
+
+// ImportError is returned if a module imports anything but the functions of
+// the Ethereum Environment Interface or the bignum namespace with their exact
+// signatures.
+type ImportError struct {
+	Module string
+	Field  string
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("ewasm: import %s.%s is not allowed", e.Module, e.Field)
+}
+
+// Validate checks that the module only uses the deterministic subset of wasm
+// allowed for ewasm contracts:
+//
+//   - no floating point types or instructions
+//   - exactly one memory and bounded tables
+//   - only imports of EEI and bignum host functions
+//   - no start function
+//   - only the main function and the memory exported
+func Validate(m *Module) error {
+	// Check the signatures and imports against the host functions
+	for _, ft := range m.Types {
+		if hasFloat(ft.Params) || hasFloat(ft.Results) {
+			return ErrFloatingPoint
+		}
+	}
+	for _, imp := range m.Imports {
+		if imp.Kind != ExternalFunction {
+			return &ImportError{imp.Module, imp.Field}
+		}
+		want, ok := hostModules[imp.Module][imp.Field]
+		if !ok {
+			return &ImportError{imp.Module, imp.Field}
+		}
+		if uint64(imp.TypeIndex) >= uint64(len(m.Types)) {
+			return ErrInvalidTypeRef
+		}
+		if !m.Types[imp.TypeIndex].Equal(want) {
+			return &ImportError{imp.Module, imp.Field}
+		}
+	}
+	for _, index := range m.Functions {
+		if uint64(index) >= uint64(len(m.Types)) {
+			return ErrInvalidTypeRef
+		}
+	}
+	// Check the memory and table limits
+	if len(m.Memories) != 1 {
+		return ErrMemoryCount
+	}
+	for _, table := range m.Tables {
+		if !table.Limits.HasMax {
+			return ErrUnboundedTable
+		}
+		if table.Limits.Max > MaxTableSize {
+			return ErrTableTooLarge
+		}
+	}
+	for _, elem := range m.Elements {
+		if uint64(elem.Table) >= uint64(len(m.Tables)) {
+			return ErrInvalidTableRef
+		}
+		for _, index := range elem.Funcs {
+			if m.FuncType(index) == nil {
+				return ErrInvalidFuncRef
+			}
+		}
+	}
+	for _, global := range m.Globals {
+		if isFloat(global.Type.Type) {
+			return ErrFloatingPoint
+		}
+		if err := validateCode(global.Init); err != nil {
+			return err
+		}
+	}
+	// Check the entry points of the contract
+	if m.Start != nil {
+		return ErrStartFunction
+	}
+	if err := validateExports(m); err != nil {
+		return err
+	}
+	// Check the function bodies for non-deterministic instructions
+	for _, body := range m.Code {
+		for _, local := range body.Locals {
+			if isFloat(local.Type) {
+				return ErrFloatingPoint
+			}
+		}
+		if err := validateCode(body.Code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateExports checks that main and memory are the only exports, with main
+// having an empty signature.
+func validateExports(m *Module) error {
+	if len(m.Exports) != 2 {
+		return ErrInvalidExports
+	}
+	var main, memory bool
+	for _, exp := range m.Exports {
+		switch {
+		case exp.Name == MainExport && exp.Kind == ExternalFunction:
+			ft := m.FuncType(exp.Index)
+			if ft == nil {
+				return ErrInvalidFuncRef
+			}
+			if len(ft.Params) != 0 || len(ft.Results) != 0 {
+				return ErrMainSignature
+			}
+			main = true
+
+		case exp.Name == MemoryExport && exp.Kind == ExternalMemory:
+			if exp.Index != 0 {
+				return ErrInvalidExports
+			}
+			memory = true
+
+		default:
+			return ErrInvalidExports
+		}
+	}
+	if !main || !memory {
+		return ErrInvalidExports
+	}
+	return nil
+}
+
+// validateCode walks an instruction stream, rejecting floating point
+// instructions.
+func validateCode(code []byte) error {
+	r := &reader{buf: code}
+	for !r.done() {
+		op, _, err := r.instruction()
+		if err != nil {
+			return err
+		}
+		if isFloatOp(op) {
+			return ErrFloatingPoint
+		}
+	}
+	return nil
+}
+
+func isFloat(t ValueType) bool {
+	return t == F32 || t == F64
+}
+
+func hasFloat(types []ValueType) bool {
+	for _, t := range types {
+		if isFloat(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFloatOp reports whether the opcode operates on, produces or consumes
+// floating point values.
+func isFloatOp(op byte) bool {
+	switch {
+	case op == 0x2a || op == 0x2b: // f32.load, f64.load
+		return true
+	case op == 0x38 || op == 0x39: // f32.store, f64.store
+		return true
+	case op == OpF32Const || op == OpF64Const:
+		return true
+	case op >= 0x5b && op <= 0x66: // f32 and f64 comparisons
+		return true
+	case op >= 0x8b && op <= 0xa6: // f32 and f64 arithmetic
+		return true
+	case op >= 0xa8 && op <= 0xab: // i32.trunc_f32_s .. i32.trunc_f64_u
+		return true
+	case op >= 0xae && op <= 0xbf: // i64.trunc_*, conversions, reinterpretations
+		return true
+	}
+	return false
+}
+
+// ValidateAndMeter decodes a wasm binary, checks it against the ewasm rules and
+// returns it with gas metering injected. This is the processing the sentinel
+// contract applies to every deployed module.
+func ValidateAndMeter(code []byte) ([]byte, error) {
+	m, err := Decode(code)
+	if err != nil {
+		return nil, err
+	}
+	if err := Validate(m); err != nil {
+		return nil, err
+	}
+	if err := Meter(m, DefaultCostTable); err != nil {
+		return nil, err
+	}
+	return m.Encode(), nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ewasm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(m *Module)
+		err    error
+	}{
+		{"valid", func(m *Module) {}, nil},
+		{"float param", func(m *Module) {
+			m.Types[1].Params = []ValueType{F64}
+		}, ErrFloatingPoint},
+		{"float local", func(m *Module) {
+			m.Code[0].Locals = []Local{{Count: 1, Type: F32}}
+		}, ErrFloatingPoint},
+		{"float instruction", func(m *Module) {
+			m.Code[0].Code = common.FromHex("0x43000000001a0b")
+		}, ErrFloatingPoint},
+		{"float conversion", func(m *Module) {
+			m.Code[0].Code = common.FromHex("0x4100b21a0b")
+		}, ErrFloatingPoint},
+		{"integer conversion", func(m *Module) {
+			m.Code[0].Code = common.FromHex("0x4100ac1a0b")
+		}, nil},
+		{"float global", func(m *Module) {
+			m.Globals = []Global{{Type: GlobalType{Type: F32}, Init: common.FromHex("0x43000000000b")}}
+		}, ErrFloatingPoint},
+		{"foreign module", func(m *Module) {
+			m.Imports[0].Module = "env"
+		}, &ImportError{"env", "finish"}},
+		{"unknown host function", func(m *Module) {
+			m.Imports[0].Field = "exit"
+		}, &ImportError{"ethereum", "exit"}},
+		{"host function signature", func(m *Module) {
+			m.Imports[0].TypeIndex = 1
+		}, &ImportError{"ethereum", "finish"}},
+		{"bignum host function", func(m *Module) {
+			m.Types = append(m.Types, Bignum["mul256"])
+			m.Imports = append(m.Imports, Import{Module: BignumModule, Field: "mul256", Kind: ExternalFunction, TypeIndex: 2})
+			m.Exports[1].Index = 2
+		}, nil},
+		{"bignum modular addition", func(m *Module) {
+			m.Types = append(m.Types, Bignum["addmod256"])
+			m.Imports = append(m.Imports, Import{Module: BignumModule, Field: "addmod256", Kind: ExternalFunction, TypeIndex: 2})
+			m.Exports[1].Index = 2
+		}, nil},
+		{"unknown bignum host function", func(m *Module) {
+			m.Imports[0].Module = BignumModule
+		}, &ImportError{"bignum", "finish"}},
+		{"imported memory", func(m *Module) {
+			m.Imports = append(m.Imports, Import{Module: "ethereum", Field: "memory", Kind: ExternalMemory})
+		}, &ImportError{"ethereum", "memory"}},
+		{"no memory", func(m *Module) {
+			m.Memories = nil
+		}, ErrMemoryCount},
+		{"multiple memories", func(m *Module) {
+			m.Memories = append(m.Memories, Limits{Min: 1})
+		}, ErrMemoryCount},
+		{"unbounded table", func(m *Module) {
+			m.Tables = []Table{{ElemType: elemTypeAnyFunc, Limits: Limits{Min: 1}}}
+		}, ErrUnboundedTable},
+		{"oversized table", func(m *Module) {
+			m.Tables = []Table{{ElemType: elemTypeAnyFunc, Limits: Limits{Min: 1, Max: MaxTableSize + 1, HasMax: true}}}
+		}, ErrTableTooLarge},
+		{"bounded table", func(m *Module) {
+			m.Tables = []Table{{ElemType: elemTypeAnyFunc, Limits: Limits{Min: 1, Max: MaxTableSize, HasMax: true}}}
+			m.Elements = []Element{{Offset: common.FromHex("0x41000b"), Funcs: []uint32{1}}}
+		}, nil},
+		{"dangling element", func(m *Module) {
+			m.Tables = []Table{{ElemType: elemTypeAnyFunc, Limits: Limits{Min: 1, Max: 1, HasMax: true}}}
+			m.Elements = []Element{{Offset: common.FromHex("0x41000b"), Funcs: []uint32{2}}}
+		}, ErrInvalidFuncRef},
+		{"start function", func(m *Module) {
+			start := uint32(1)
+			m.Start = &start
+		}, ErrStartFunction},
+		{"missing main", func(m *Module) {
+			m.Exports = m.Exports[:1]
+		}, ErrInvalidExports},
+		{"extra export", func(m *Module) {
+			m.Exports[1].Name = "other"
+		}, ErrInvalidExports},
+		{"main signature", func(m *Module) {
+			m.Exports[1].Index = 0
+		}, ErrMainSignature},
+	}
+	for _, tt := range tests {
+		m, err := Decode(finishContract)
+		if err != nil {
+			t.Fatalf("failed to decode module: %v", err)
+		}
+		tt.modify(m)
+		if err := Validate(m); !reflect.DeepEqual(err, tt.err) {
+			t.Errorf("%s: validation error mismatch: have %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestValidateAndMeter(t *testing.T) {
+	metered, err := ValidateAndMeter(finishContract)
+	if err != nil {
+		t.Fatalf("failed to process valid module: %v", err)
+	}
+	// The metered module must still adhere to the rules
+	m, err := Decode(metered)
+	if err != nil {
+		t.Fatalf("failed to decode metered module: %v", err)
+	}
+	if err := Validate(m); err != nil {
+		t.Errorf("metered module invalid: %v", err)
+	}
+	m.Start = new(uint32)
+	if _, err := ValidateAndMeter(m.Encode()); err != ErrStartFunction {
+		t.Errorf("invalid module error mismatch: have %v, want %v", err, ErrStartFunction)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"github.com/ethereum/go-ethereum/core/vm/ewasm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Format is the encoding of a contract's code, deciding which interpreter is
+// able to execute it.
+type Format int
+
+const (
+	FormatLegacy Format = iota // Plain EVM bytecode
+	FormatWasm                 // ewasm module
+)
+
+// String implements the fmt.Stringer interface.
+func (f Format) String() string {
+	switch f {
+	case FormatLegacy:
+		return "legacy"
+	case FormatWasm:
+		return "wasm"
+	}
+	return "unknown"
+}
+
+// CodeFormat classifies the given code based on its preamble.
+func CodeFormat(code []byte) Format {
+	if ewasm.IsWasm(code) {
+		return FormatWasm
+	}
+	return FormatLegacy
+}
+
+// codeFormat classifies the given code according to the active chain rules.
+// Before the ewasm fork every piece of code is EVM bytecode, whatever it starts
+// with.
+func codeFormat(rules params.Rules, code []byte) Format {
+	if !rules.IsEWASM {
+		return FormatLegacy
+	}
+	return CodeFormat(code)
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestCodeFormat(t *testing.T) {
+	tests := []struct {
+		code   string
+		format Format
+	}{
+		{"", FormatLegacy},
+		{"00", FormatLegacy},
+		{"0061736d", FormatWasm},
+		{"0061736d01000000", FormatWasm},
+		{"6000600055", FormatLegacy},
+		{"ef00010100", FormatLegacy},
+	}
+	for i, tt := range tests {
+		if have := CodeFormat(common.Hex2Bytes(tt.code)); have != tt.format {
+			t.Errorf("test %d: format mismatch: have %v, want %v", i, have, tt.format)
+		}
+	}
+}
+
+// Tests that wasm code is only kept away from the EVM interpreter once the
+// ewasm fork is active.
+func TestInterpreterCanRunFormat(t *testing.T) {
+	wasm := common.Hex2Bytes("0061736d01000000")
+	evm := common.Hex2Bytes("6000600055")
+
+	in := &EVMInterpreter{evm: &EVM{chainRules: params.Rules{}}}
+	if !in.CanRun(wasm) || !in.CanRun(evm) {
+		t.Errorf("EVM interpreter rejected code before ewasm")
+	}
+	in = &EVMInterpreter{evm: &EVM{chainRules: params.Rules{IsEWASM: true}}}
+	if in.CanRun(wasm) {
+		t.Errorf("EVM interpreter accepted wasm code under ewasm rules")
+	}
+	if !in.CanRun(evm) {
+		t.Errorf("EVM interpreter rejected evm code under ewasm rules")
+	}
+}
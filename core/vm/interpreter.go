@@ -37,6 +37,10 @@ type Config struct {
 
 	EWASMInterpreter string // External EWASM interpreter options
 	EVMInterpreter   string // External EVM interpreter options
+
+	Interpreters []InterpreterFactory // Custom interpreters, tried before the built-in ones
+
+	OpcodeCounter *OpcodeCounter // Tally of the executed opcodes, nil if disabled
 }
 
 // Interpreter is used to run Ethereum based contracts and will utilise the
@@ -61,6 +65,11 @@ type Interpreter interface {
 	CanRun([]byte) bool
 }
 
+// InterpreterFactory creates an interpreter running code on behalf of the given
+// EVM. It allows instrumented or alternative interpreters to be plugged into
+// the EVM through its Config.
+type InterpreterFactory func(evm *EVM, cfg Config) Interpreter
+
 // keccakState wraps sha3.state. In addition to the usual hash methods, it also supports
 // Read to get a variable amount of data from the hash state. Read is faster than Sum
 // because it doesn't copy the internal state, but also modifies the internal state.
@@ -82,6 +91,9 @@ type EVMInterpreter struct {
 
 	readOnly   bool   // Whether to throw on stateful modifications
 	returnData []byte // Last CALL's return data for subsequent reuse
+
+	opcodeCounts *[256]uint64 // Opcodes executed by the current call, nil if not counting
+	frames       int          // Number of nested Run invocations of the current call
 }
 
 // NewEVMInterpreter returns a new instance of the Interpreter.
@@ -90,23 +102,18 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 	// the jump table was initialised. If it was not
 	// we'll set the default jump table.
 	if !cfg.JumpTable[STOP].valid {
-		switch {
-		case evm.ChainConfig().IsConstantinople(evm.BlockNumber):
-			cfg.JumpTable = constantinopleInstructionSet
-		case evm.ChainConfig().IsByzantium(evm.BlockNumber):
-			cfg.JumpTable = byzantiumInstructionSet
-		case evm.ChainConfig().IsHomestead(evm.BlockNumber):
-			cfg.JumpTable = homesteadInstructionSet
-		default:
-			cfg.JumpTable = frontierInstructionSet
-		}
+		cfg.JumpTable = *instructionSet(evm.chainRules)
 	}
 
-	return &EVMInterpreter{
+	in := &EVMInterpreter{
 		evm:      evm,
 		cfg:      cfg,
 		gasTable: evm.ChainConfig().GasTable(evm.BlockNumber),
 	}
+	if cfg.OpcodeCounter != nil {
+		in.opcodeCounts = new([256]uint64)
+	}
+	return in
 }
 
 // Run loops and evaluates the contract's code with the given input data and returns
@@ -128,6 +135,17 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	in.evm.depth++
 	defer func() { in.evm.depth-- }()
 
+	// Hand the opcodes tallied during the call over once its outermost frame
+	// returns, keeping the shared counter out of the hot loop.
+	if in.opcodeCounts != nil {
+		in.frames++
+		defer func() {
+			if in.frames--; in.frames == 0 {
+				in.cfg.OpcodeCounter.add(in.opcodeCounts)
+			}
+		}()
+	}
+
 	// Make sure the readOnly is only set if we aren't in readOnly yet.
 	// This makes also sure that the readOnly flag isn't removed for child calls.
 	if readOnly && !in.readOnly {
@@ -210,6 +228,7 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 			}
 		}
 		// Static portion of gas
+		cost = operation.constantGas // For tracing
 		if !contract.UseGas(operation.constantGas) {
 			return nil, ErrOutOfGas
 		}
@@ -234,8 +253,10 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		// consume the gas and return an error if not enough gas is available.
 		// cost is explicitly set so that the capture state defer method can get the proper cost
 		if operation.dynamicGas != nil {
-			cost, err = operation.dynamicGas(in.gasTable, in.evm, contract, stack, mem, memorySize)
-			if err != nil || !contract.UseGas(cost) {
+			var dynamicCost uint64
+			dynamicCost, err = operation.dynamicGas(in.gasTable, in.evm, contract, stack, mem, memorySize)
+			cost += dynamicCost // total cost, for tracing
+			if err != nil || !contract.UseGas(dynamicCost) {
 				return nil, ErrOutOfGas
 			}
 		}
@@ -248,6 +269,9 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 			logged = true
 		}
 
+		if in.opcodeCounts != nil {
+			in.opcodeCounts[op]++
+		}
 		// execute the operation
 		res, err = operation.execute(&pc, in, contract, mem, stack)
 		// verifyPool is a build flag. Pool verification makes sure the integrity
@@ -278,5 +302,5 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 // CanRun tells if the contract, passed as an argument, can be
 // run by the current interpreter.
 func (in *EVMInterpreter) CanRun(code []byte) bool {
-	return true
+	return codeFormat(in.evm.chainRules, code) == FormatLegacy
 }
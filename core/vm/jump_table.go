@@ -58,8 +58,68 @@ var (
 	homesteadInstructionSet      = newHomesteadInstructionSet()
 	byzantiumInstructionSet      = newByzantiumInstructionSet()
 	constantinopleInstructionSet = newConstantinopleInstructionSet()
+	eip3855InstructionSet        = newEIP3855InstructionSet()
 )
 
+// instructionSet returns the instruction set EVM bytecode is executed with
+// under the given chain rules.
+func instructionSet(rules params.Rules) *[256]operation {
+	switch {
+	case rules.IsEIP3855:
+		return &eip3855InstructionSet
+	case rules.IsConstantinople:
+		return &constantinopleInstructionSet
+	case rules.IsByzantium:
+		return &byzantiumInstructionSet
+	case rules.IsHomestead:
+		return &homesteadInstructionSet
+	default:
+		return &frontierInstructionSet
+	}
+}
+
+// OpcodeInfo describes an opcode of an instruction set.
+type OpcodeInfo struct {
+	Op         OpCode `json:"op"`
+	Name       string `json:"name"`
+	Immediates int    `json:"immediates"` // Number of immediate bytes following the opcode
+}
+
+// ActiveOpcodes returns the opcodes that are valid in EVM bytecode under the
+// given chain rules, ordered by their value.
+func ActiveOpcodes(rules params.Rules) []OpcodeInfo {
+	var (
+		set     = instructionSet(rules)
+		opcodes []OpcodeInfo
+	)
+	for i := range set {
+		if !set[i].valid {
+			continue
+		}
+		op := OpCode(i)
+		info := OpcodeInfo{Op: op, Name: op.String()}
+		if op.IsPush() {
+			info.Immediates = int(op-PUSH1) + 1
+		}
+		opcodes = append(opcodes, info)
+	}
+	return opcodes
+}
+
+// newEIP3855InstructionSet returns the constantinople instructions extended
+// with the PUSH0 instruction of EIP-3855.
+func newEIP3855InstructionSet() [256]operation {
+	instructionSet := newConstantinopleInstructionSet()
+	instructionSet[PUSH0] = operation{
+		execute:     opPush0,
+		constantGas: GasQuickStep,
+		minStack:    minStack(0, 1),
+		maxStack:    maxStack(0, 1),
+		valid:       true,
+	}
+	return instructionSet
+}
+
 // NewConstantinopleInstructionSet returns the frontier, homestead
 // byzantium and contantinople instructions.
 func newConstantinopleInstructionSet() [256]operation {
@@ -0,0 +1,63 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the active opcodes follow the fork rules.
+func TestActiveOpcodes(t *testing.T) {
+	tests := []struct {
+		rules   params.Rules
+		op      OpCode
+		present bool
+	}{
+		{params.Rules{}, DELEGATECALL, false},
+		{params.Rules{IsHomestead: true}, DELEGATECALL, true},
+		{params.Rules{IsHomestead: true}, REVERT, false},
+		{params.Rules{IsByzantium: true}, REVERT, true},
+		{params.Rules{IsByzantium: true}, CREATE2, false},
+		{params.Rules{IsConstantinople: true}, CREATE2, true},
+		{params.Rules{IsConstantinople: true}, PUSH0, false},
+		{params.Rules{IsEIP3855: true}, PUSH0, true},
+		{params.Rules{IsEIP3855: true}, OpCode(0xfe), false},
+	}
+	for i, tt := range tests {
+		var present bool
+		for _, info := range ActiveOpcodes(tt.rules) {
+			if info.Op == tt.op {
+				present = true
+			}
+		}
+		if present != tt.present {
+			t.Errorf("test %d: %v presence mismatch: have %v, want %v", i, tt.op, present, tt.present)
+		}
+	}
+	// Immediates must be reported for the push opcodes only
+	for _, info := range ActiveOpcodes(params.Rules{IsEIP3855: true}) {
+		want := 0
+		if info.Op >= PUSH1 && info.Op <= PUSH32 {
+			want = int(info.Op-PUSH1) + 1
+		}
+		if info.Immediates != want || info.Name != info.Op.String() {
+			t.Errorf("%v: info mismatch: have %+v, want %d immediates", info.Op, info, want)
+		}
+	}
+}
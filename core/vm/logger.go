@@ -104,6 +104,20 @@ type Tracer interface {
 	CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error
 }
 
+// CodeValidationTracer is an optional extension of Tracer, which is also told
+// about every piece of wasm code the sentinel contract validated, be it init
+// code or code about to be deployed. The code hash is that of the code before
+// metering.
+//
+// Unlike the other capture methods, CaptureCodeValidation is called even if
+// Config.Debug is off, so a tracer may observe validations without tracing
+// every opcode. It isn't called if the sentinel contract never judged the code
+// because the call depth limit was hit or the validation couldn't be paid for.
+type CodeValidationTracer interface {
+	Tracer
+	CaptureCodeValidation(codeHash common.Hash, valid bool, err error, t time.Duration) error
+}
+
 // StructLogger is an EVM state logger and implements Tracer.
 //
 // StructLogger can capture state based on the given Log configuration and also keeps
@@ -0,0 +1,37 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import "testing"
+
+// Tests that the opcode names and their reverse lookup table agree.
+func TestOpcodeNames(t *testing.T) {
+	for op, name := range opCodeToString {
+		// The pseudo opcodes are named, but never parsed
+		if op == PUSH || op == DUP || op == SWAP {
+			continue
+		}
+		if have := StringToOp(name); have != op {
+			t.Errorf("%s: opcode mismatch: have %#x, want %#x", name, byte(have), byte(op))
+		}
+	}
+	for name, op := range stringToOp {
+		if have := op.String(); have != name {
+			t.Errorf("%#x: name mismatch: have %s, want %s", byte(op), have, name)
+		}
+	}
+}
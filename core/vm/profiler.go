@@ -0,0 +1,277 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// OpcodeStat is the number of executions of an opcode and the gas they were
+// charged in total.
+type OpcodeStat struct {
+	Op    OpCode `json:"-"`
+	Name  string `json:"name"`
+	Count uint64 `json:"count"`
+	Gas   uint64 `json:"gas"`
+}
+
+// OpcodeProfiler is an EVM tracer aggregating how often every opcode was
+// executed and how much gas it was charged, in total and optionally per call
+// stack. Unlike the StructLogger it keeps no per-step state, so without the call
+// tree it is cheap enough to be run over many transactions.
+//
+// The gas forwarded to a callee is left out of the cost of the call opcodes, it
+// is accounted to the instructions run by the callee instead.
+type OpcodeProfiler struct {
+	counts [256]uint64
+	gas    [256]uint64
+
+	root *profileNode   // Root of the call tree, nil if not recorded
+	path []*profileNode // Frames of the call stack being executed, root first
+}
+
+// profileNode is a frame of the call tree built by the OpcodeProfiler.
+type profileNode struct {
+	calls map[common.Address]*profileNode // Frames of the contracts called
+	ops   map[OpCode]*OpcodeStat          // Opcodes executed by the frame itself
+}
+
+func newProfileNode() *profileNode {
+	return &profileNode{
+		calls: make(map[common.Address]*profileNode),
+		ops:   make(map[OpCode]*OpcodeStat),
+	}
+}
+
+// NewOpcodeProfiler creates a new EVM tracer counting opcode executions. If
+// frames is set, it also records the call tree returned by Frames, growing with
+// every contract called.
+func NewOpcodeProfiler(frames bool) *OpcodeProfiler {
+	if !frames {
+		return new(OpcodeProfiler)
+	}
+	root := newProfileNode()
+	return &OpcodeProfiler{root: root, path: []*profileNode{root}}
+}
+
+func (p *OpcodeProfiler) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	return nil
+}
+
+// CaptureState accounts the executed opcode and its cost.
+func (p *OpcodeProfiler) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	switch op {
+	case CALL, CALLCODE, DELEGATECALL, STATICCALL:
+		// The gas handed to the callee is only known once the call was priced
+		if err == nil && cost >= env.callGasTemp {
+			cost -= env.callGasTemp
+		}
+	}
+	p.counts[op]++
+	p.gas[op] += cost
+
+	if p.root == nil {
+		return nil
+	}
+	frame := p.frame(contract, depth)
+	stat := frame.ops[op]
+	if stat == nil {
+		stat = &OpcodeStat{Op: op, Name: op.String()}
+		frame.ops[op] = stat
+	}
+	stat.Count++
+	stat.Gas += cost
+	return nil
+}
+
+// frame returns the node of the call tree the given contract is executed in at
+// the given call depth, entering and leaving frames as needed. Calls to the same
+// contract from the same frame share a node.
+func (p *OpcodeProfiler) frame(contract *Contract, depth int) *profileNode {
+	addr := contract.Address()
+	if contract.CodeAddr != nil {
+		addr = *contract.CodeAddr
+	}
+	// Leave the frames that returned, including a sibling call to another
+	// contract that finished at the same depth
+	if len(p.path) > depth+1 {
+		p.path = p.path[:depth+1]
+	}
+	if len(p.path) == depth+1 && p.path[depth-1].calls[addr] != p.path[depth] {
+		p.path = p.path[:depth]
+	}
+	// Enter the new frames. Frames without any opcodes of their own, such as
+	// ewasm contracts, are not seen and show up as the zero address.
+	for len(p.path) <= depth {
+		key := addr
+		if len(p.path) < depth {
+			key = common.Address{}
+		}
+		parent := p.path[len(p.path)-1]
+		node := parent.calls[key]
+		if node == nil {
+			node = newProfileNode()
+			parent.calls[key] = node
+		}
+		p.path = append(p.path, node)
+	}
+	return p.path[depth]
+}
+
+func (p *OpcodeProfiler) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	return nil
+}
+
+func (p *OpcodeProfiler) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	return nil
+}
+
+// Stats returns the statistics of all executed opcodes, ordered by descending
+// gas usage and then by opcode.
+func (p *OpcodeProfiler) Stats() []OpcodeStat {
+	var stats []OpcodeStat
+	for i, count := range p.counts {
+		if count == 0 {
+			continue
+		}
+		op := OpCode(i)
+		stats = append(stats, OpcodeStat{Op: op, Name: op.String(), Count: count, Gas: p.gas[i]})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Gas != stats[j].Gas {
+			return stats[i].Gas > stats[j].Gas
+		}
+		return stats[i].Op < stats[j].Op
+	})
+	return stats
+}
+
+// ProfileFrame is a frame of the call tree recorded by an OpcodeProfiler, laid
+// out like the input of flame graph viewers such as d3-flame-graph. Call frames
+// are named after the address of the executed code, their leaves after the
+// opcodes executed by the frame itself.
+type ProfileFrame struct {
+	Name     string          `json:"name"`
+	Value    uint64          `json:"value"`           // Gas charged within the frame, children included
+	Count    uint64          `json:"count,omitempty"` // Number of executions of a leaf opcode
+	Children []*ProfileFrame `json:"children,omitempty"`
+}
+
+// Frames returns the call tree of all executed opcodes, or nil if the profiler
+// doesn't record it. The root frame spans all the traced calls.
+func (p *OpcodeProfiler) Frames() *ProfileFrame {
+	if p.root == nil {
+		return nil
+	}
+	return p.root.export("all")
+}
+
+// export converts the node into a profile frame, ordering the calls by address
+// and the opcodes of the frame itself after them.
+func (n *profileNode) export(name string) *ProfileFrame {
+	frame := &ProfileFrame{Name: name}
+
+	addrs := make([]common.Address, 0, len(n.calls))
+	for addr := range n.calls {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	for _, addr := range addrs {
+		child := n.calls[addr].export(addr.Hex())
+		frame.Value += child.Value
+		frame.Children = append(frame.Children, child)
+	}
+	ops := make([]*OpcodeStat, 0, len(n.ops))
+	for _, stat := range n.ops {
+		ops = append(ops, stat)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Op < ops[j].Op })
+	for _, stat := range ops {
+		frame.Value += stat.Gas
+		frame.Children = append(frame.Children, &ProfileFrame{Name: stat.Name, Value: stat.Gas, Count: stat.Count})
+	}
+	return frame
+}
+
+// OpcodeCounter tallies the executed opcodes without tracing them, along with
+// the executed code frames by code format. It can be installed into the EVM
+// configuration of a live node, and is safe for use by concurrently running
+// EVMs: the interpreter counts the opcodes of each call on its own and adds
+// them to the counter once the call returns.
+type OpcodeCounter struct {
+	counts  [256]uint64
+	formats [FormatWasm + 1]uint64 // Accessed atomically
+	lock    sync.Mutex
+}
+
+// NewOpcodeCounter creates a new, zeroed opcode counter.
+func NewOpcodeCounter() *OpcodeCounter {
+	return new(OpcodeCounter)
+}
+
+// add accounts the opcodes tallied by a call and resets the tally.
+func (c *OpcodeCounter) add(counts *[256]uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for i, count := range counts {
+		c.counts[i] += count
+	}
+	*counts = [256]uint64{}
+}
+
+// Counts returns the number of executions of every opcode executed so far,
+// keyed by the opcode names.
+func (c *OpcodeCounter) Counts() map[string]uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	counts := make(map[string]uint64)
+	for i, count := range c.counts {
+		if count > 0 {
+			counts[OpCode(i).String()] = count
+		}
+	}
+	return counts
+}
+
+// addFrame accounts the start of a frame executing code of the given format.
+func (c *OpcodeCounter) addFrame(format Format) {
+	atomic.AddUint64(&c.formats[format], 1)
+}
+
+// Formats returns the number of code frames executed so far, keyed by the name
+// of the format of their code. Calls to precompiled contracts and accounts
+// without code are not counted.
+func (c *OpcodeCounter) Formats() map[string]uint64 {
+	formats := make(map[string]uint64)
+	for i := range c.formats {
+		if count := atomic.LoadUint64(&c.formats[i]); count > 0 {
+			formats[Format(i).String()] = count
+		}
+	}
+	return formats
+}
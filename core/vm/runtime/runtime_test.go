@@ -18,6 +18,7 @@ package runtime
 
 import (
 	"math/big"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -94,6 +95,162 @@ func TestExecute(t *testing.T) {
 	}
 }
 
+// Tests that PUSH0 is only available once EIP-3855 is activated.
+func TestPush0(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH1), 10,
+		byte(vm.PUSH0),
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH0),
+		byte(vm.RETURN),
+	}
+	if _, _, err := Execute(code, nil, nil); err == nil {
+		t.Fatal("PUSH0 accepted before EIP-3855")
+	}
+	cfg := &Config{ChainConfig: &params.ChainConfig{
+		ChainID:             big.NewInt(1),
+		HomesteadBlock:      new(big.Int),
+		ByzantiumBlock:      new(big.Int),
+		ConstantinopleBlock: new(big.Int),
+		EIP3855Block:        new(big.Int),
+	}}
+	ret, _, err := Execute(code, nil, cfg)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if num := new(big.Int).SetBytes(ret); num.Cmp(big.NewInt(10)) != 0 {
+		t.Error("Expected 10, got", num)
+	}
+}
+
+// Tests that the cost handed to tracers covers both the constant and dynamic
+// gas of every opcode, without leaking into the opcodes following it.
+func TestStructLoggerCost(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH1), 1,
+		byte(vm.PUSH1), 0,
+		byte(vm.SSTORE), // 20000 to set a fresh slot
+		byte(vm.PUSH1), 0,
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE), // 3 constant, 3 to expand the memory
+		byte(vm.PUSH1), 0,
+		byte(vm.POP),
+		byte(vm.STOP),
+	}
+	logger := vm.NewStructLogger(nil)
+	if _, _, err := Execute(code, nil, &Config{EVMConfig: vm.Config{Debug: true, Tracer: logger}}); err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	want := []uint64{3, 3, 20000, 3, 3, 6, 3, 2, 0}
+	logs := logger.StructLogs()
+	if len(logs) != len(want) {
+		t.Fatalf("log count mismatch: have %d, want %d", len(logs), len(want))
+	}
+	for i, log := range logs {
+		if log.GasCost != want[i] {
+			t.Errorf("log %d (%v): cost mismatch: have %d, want %d", i, log.Op, log.GasCost, want[i])
+		}
+	}
+}
+
+// Tests that the opcode profiler aggregates executions and gas per opcode.
+func TestOpcodeProfiler(t *testing.T) {
+	profiler := vm.NewOpcodeProfiler(false)
+	_, _, err := Execute([]byte{
+		byte(vm.PUSH1), 10,
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	}, nil, &Config{EVMConfig: vm.Config{Debug: true, Tracer: profiler}})
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	want := []vm.OpcodeStat{
+		{Op: vm.PUSH1, Name: "PUSH1", Count: 4, Gas: 12},
+		{Op: vm.MSTORE, Name: "MSTORE", Count: 1, Gas: 6},
+		{Op: vm.RETURN, Name: "RETURN", Count: 1, Gas: 0},
+	}
+	if have := profiler.Stats(); !reflect.DeepEqual(have, want) {
+		t.Errorf("profile mismatch: have %+v, want %+v", have, want)
+	}
+	if frames := profiler.Frames(); frames != nil {
+		t.Errorf("call tree recorded without being requested: %+v", frames)
+	}
+}
+
+// Tests that the opcode profiler attributes the opcodes to the frames of the
+// call tree they were executed in.
+func TestOpcodeProfilerFrames(t *testing.T) {
+	state, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	callee := common.HexToAddress("0xbbbb")
+	state.SetCode(callee, []byte{byte(vm.PUSH1), 0, byte(vm.POP), byte(vm.STOP)})
+
+	profiler := vm.NewOpcodeProfiler(true)
+	_, _, err := Execute([]byte{
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH2), 0xbb, 0xbb,
+		byte(vm.PUSH2), 0xff, 0xff,
+		byte(vm.CALL),
+		byte(vm.STOP),
+	}, nil, &Config{State: state, EVMConfig: vm.Config{Debug: true, Tracer: profiler}})
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	root := profiler.Frames()
+	if len(root.Children) != 1 || root.Children[0].Name != common.BytesToAddress([]byte("contract")).Hex() {
+		t.Fatalf("caller frame missing: %+v", root.Children)
+	}
+	caller := root.Children[0]
+	var names []string
+	for _, child := range caller.Children {
+		names = append(names, child.Name)
+	}
+	if want := []string{callee.Hex(), "STOP", "PUSH1", "PUSH2", "CALL"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("caller children mismatch: have %v, want %v", names, want)
+	}
+	want := &vm.ProfileFrame{Name: callee.Hex(), Value: 5, Children: []*vm.ProfileFrame{
+		{Name: "STOP", Value: 0, Count: 1},
+		{Name: "POP", Value: 2, Count: 1},
+		{Name: "PUSH1", Value: 3, Count: 1},
+	}}
+	if !reflect.DeepEqual(caller.Children[0], want) {
+		t.Errorf("callee frame mismatch: have %+v, want %+v", caller.Children[0], want)
+	}
+	if call := caller.Children[4]; call.Value != params.GasTableEIP150.Calls {
+		t.Errorf("call gas mismatch: have %d, want %d", call.Value, params.GasTableEIP150.Calls)
+	}
+	if root.Value != caller.Value {
+		t.Errorf("root value mismatch: have %d, want %d", root.Value, caller.Value)
+	}
+}
+
+func TestOpcodeCounter(t *testing.T) {
+	counter := vm.NewOpcodeCounter()
+	code := []byte{
+		byte(vm.PUSH1), 10,
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, err := Execute(code, nil, &Config{EVMConfig: vm.Config{OpcodeCounter: counter}}); err != nil {
+			t.Fatal("didn't expect error", err)
+		}
+	}
+	want := map[string]uint64{"PUSH1": 8, "MSTORE": 2, "RETURN": 2}
+	if have := counter.Counts(); !reflect.DeepEqual(have, want) {
+		t.Errorf("counts mismatch: have %v, want %v", have, want)
+	}
+	if have := counter.Formats(); !reflect.DeepEqual(have, map[string]uint64{"legacy": 2}) {
+		t.Errorf("frame formats mismatch: have %v, want map[legacy:2]", have)
+	}
+}
+
 func TestCall(t *testing.T) {
 	state, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
 	address := common.HexToAddress("0x0a")
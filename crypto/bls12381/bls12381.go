@@ -0,0 +1,231 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package bls12381 implements the BLS12-381 pairing friendly elliptic curve,
+// with points encoded as specified by EIP-2537.
+//
+// This is a straightforward implementation on top of math/big, favouring
+// readability over speed. It is not constant time and hence must not be used
+// with secret inputs. The EIP-2537 pre-compiles built on it are priced after its
+// measured cost rather than the schedule of the EIP.
+package bls12381
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Sizes of the encodings defined by EIP-2537. Field elements are padded from
+// 48 to 64 bytes with leading zeroes.
+const (
+	FpSize = 64
+	G1Size = 2 * FpSize
+	G2Size = 4 * FpSize
+)
+
+var (
+	ErrInvalidFieldElement = errors.New("bls12381: invalid field element")
+	ErrNotOnCurve          = errors.New("bls12381: point is not on curve")
+	errShortInput          = errors.New("bls12381: not enough data")
+)
+
+// unmarshalFp decodes a padded base field element, rejecting non-zero padding
+// and non-canonical values.
+func unmarshalFp(m []byte) (*big.Int, error) {
+	for _, b := range m[:FpSize-48] {
+		if b != 0 {
+			return nil, ErrInvalidFieldElement
+		}
+	}
+	n := new(big.Int).SetBytes(m[FpSize-48 : FpSize])
+	if n.Cmp(P) >= 0 {
+		return nil, ErrInvalidFieldElement
+	}
+	return n, nil
+}
+
+func marshalFp(out []byte, n *big.Int) {
+	b := n.Bytes()
+	copy(out[FpSize-len(b):FpSize], b)
+}
+
+// G1 is a point of the group on the curve over the base field.
+type G1 struct {
+	p point
+}
+
+// G1Generator returns the generator of G1.
+func G1Generator() *G1 {
+	return &G1{g1Gen}
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and then
+// returns e.
+func (e *G1) ScalarBaseMult(k *big.Int) *G1 {
+	e.p = g1Gen.mul(k)
+	return e
+}
+
+// ScalarMult sets e to a*k, for a non-negative k, and then returns e.
+func (e *G1) ScalarMult(a *G1, k *big.Int) *G1 {
+	e.p = a.p.mul(k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G1) Add(a, b *G1) *G1 {
+	e.p = a.p.add(b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G1) Neg(a *G1) *G1 {
+	e.p = a.p.neg()
+	return e
+}
+
+// IsInfinity reports whether e is the identity of the group.
+func (e *G1) IsInfinity() bool {
+	return e.p.inf
+}
+
+// InSubgroup reports whether e is in the prime order subgroup.
+func (e *G1) InSubgroup() bool {
+	return e.p.inSubgroup()
+}
+
+// Marshal converts e to its EIP-2537 encoding, with the point at infinity
+// encoded as all zeroes.
+func (e *G1) Marshal() []byte {
+	out := make([]byte, G1Size)
+	if !e.p.inf {
+		marshalFp(out, e.p.x.c0)
+		marshalFp(out[FpSize:], e.p.y.c0)
+	}
+	return out
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal back into
+// a group element, checking that it is on the curve. Membership of the prime
+// order subgroup is not checked. It returns the remainder of the input.
+func (e *G1) Unmarshal(m []byte) ([]byte, error) {
+	if len(m) < G1Size {
+		return nil, errShortInput
+	}
+	x, err := unmarshalFp(m)
+	if err != nil {
+		return nil, err
+	}
+	y, err := unmarshalFp(m[FpSize:])
+	if err != nil {
+		return nil, err
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		e.p = infinity()
+		return m[G1Size:], nil
+	}
+	p := point{x: fe2FromFp(x), y: fe2FromFp(y)}
+	if !p.onCurve(g1B) {
+		return nil, ErrNotOnCurve
+	}
+	e.p = p
+	return m[G1Size:], nil
+}
+
+// G2 is a point of the group on the sextic twist of the curve over Fp2.
+type G2 struct {
+	p point
+}
+
+// G2Generator returns the generator of G2.
+func G2Generator() *G2 {
+	return &G2{g2Gen}
+}
+
+// ScalarBaseMult sets e to g*k where g is the generator of the group and then
+// returns e.
+func (e *G2) ScalarBaseMult(k *big.Int) *G2 {
+	e.p = g2Gen.mul(k)
+	return e
+}
+
+// ScalarMult sets e to a*k, for a non-negative k, and then returns e.
+func (e *G2) ScalarMult(a *G2, k *big.Int) *G2 {
+	e.p = a.p.mul(k)
+	return e
+}
+
+// Add sets e to a+b and then returns e.
+func (e *G2) Add(a, b *G2) *G2 {
+	e.p = a.p.add(b.p)
+	return e
+}
+
+// Neg sets e to -a and then returns e.
+func (e *G2) Neg(a *G2) *G2 {
+	e.p = a.p.neg()
+	return e
+}
+
+// IsInfinity reports whether e is the identity of the group.
+func (e *G2) IsInfinity() bool {
+	return e.p.inf
+}
+
+// InSubgroup reports whether e is in the prime order subgroup.
+func (e *G2) InSubgroup() bool {
+	return e.p.inSubgroup()
+}
+
+// Marshal converts e to its EIP-2537 encoding, with the point at infinity
+// encoded as all zeroes.
+func (e *G2) Marshal() []byte {
+	out := make([]byte, G2Size)
+	if !e.p.inf {
+		marshalFp(out, e.p.x.c0)
+		marshalFp(out[FpSize:], e.p.x.c1)
+		marshalFp(out[2*FpSize:], e.p.y.c0)
+		marshalFp(out[3*FpSize:], e.p.y.c1)
+	}
+	return out
+}
+
+// Unmarshal sets e to the result of converting the output of Marshal back into
+// a group element, checking that it is on the curve. Membership of the prime
+// order subgroup is not checked. It returns the remainder of the input.
+func (e *G2) Unmarshal(m []byte) ([]byte, error) {
+	if len(m) < G2Size {
+		return nil, errShortInput
+	}
+	var coords [4]*big.Int
+	for i := range coords {
+		n, err := unmarshalFp(m[i*FpSize:])
+		if err != nil {
+			return nil, err
+		}
+		coords[i] = n
+	}
+	p := point{x: fe2{coords[0], coords[1]}, y: fe2{coords[2], coords[3]}}
+	if p.x.isZero() && p.y.isZero() {
+		e.p = infinity()
+		return m[G2Size:], nil
+	}
+	if !p.onCurve(g2B) {
+		return nil, ErrNotOnCurve
+	}
+	e.p = p
+	return m[G2Size:], nil
+}
@@ -0,0 +1,233 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+// testEncoding decodes the EIP-2537 encoding of a point from the hex strings
+// of its unpadded coordinates.
+func testEncoding(coords ...string) []byte {
+	var enc []byte
+	for _, c := range coords {
+		b, err := hex.DecodeString(c)
+		if err != nil {
+			panic(err)
+		}
+		enc = append(enc, make([]byte, FpSize-len(b))...)
+		enc = append(enc, b...)
+	}
+	return enc
+}
+
+// testFe12 returns an arbitrary element of Fp12 derived from a seed.
+func testFe12(seed int64) fe12 {
+	var coeffs [12]*big.Int
+	for i := range coeffs {
+		n := new(big.Int).Exp(big.NewInt(seed+int64(i)), big.NewInt(97), P)
+		coeffs[i] = n
+	}
+	fe := func(i int) fe2 { return fe2{coeffs[i], coeffs[i+1]} }
+	return fe12{fe6{fe(0), fe(2), fe(4)}, fe6{fe(6), fe(8), fe(10)}}
+}
+
+func TestGenerators(t *testing.T) {
+	if !g1Gen.onCurve(g1B) || !G1Generator().InSubgroup() {
+		t.Errorf("G1 generator invalid")
+	}
+	if !g2Gen.onCurve(g2B) || !G2Generator().InSubgroup() {
+		t.Errorf("G2 generator invalid")
+	}
+}
+
+// Tests the group operations against points with known encodings.
+func TestKnownPoints(t *testing.T) {
+	g1x2 := testEncoding(
+		"0572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e",
+		"166a9d8cabc673a322fda673779d8e3822ba3ecb8670e461f73bb9021d5fd76a4c56d9d4cd16bd1bba86881979749d28",
+	)
+	if have := new(G1).Add(G1Generator(), G1Generator()).Marshal(); !bytes.Equal(have, g1x2) {
+		t.Errorf("G1 doubling mismatch: have %x, want %x", have, g1x2)
+	}
+	g2x2 := testEncoding(
+		"1638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053",
+		"0a4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c33577",
+		"0468fb440d82b0630aeb8dca2b5256789a66da69bf91009cbfe6bd221e47aa8ae88dece9764bf3bd999d95d71e4c9899",
+		"0f6d4552fa65dd2638b361543f887136a43253d9c66c411697003f7a13c308f5422e1aa0a59c8967acdefd8b6e36ccf3",
+	)
+	if have := new(G2).Add(G2Generator(), G2Generator()).Marshal(); !bytes.Equal(have, g2x2) {
+		t.Errorf("G2 doubling mismatch: have %x, want %x", have, g2x2)
+	}
+	// (4, sqrt(68)) is on the curve but outside of the prime order subgroup
+	p := new(G1)
+	enc := testEncoding("04", "0a989badd40d6212b33cffc3f3763e9bc760f988c9926b26da9dd85e928483446346b8ed00e1de5d5ea93e354abe706c")
+	if _, err := p.Unmarshal(enc); err != nil {
+		t.Fatalf("failed to unmarshal point outside subgroup: %v", err)
+	}
+	if p.InSubgroup() {
+		t.Errorf("point outside subgroup accepted")
+	}
+}
+
+// Tests the field element mappings against the encode_to_curve test vectors
+// of the hash-to-curve draft (suites BLS12381G1_XMD:SHA-256_SSWU_NU_ and
+// BLS12381G2_XMD:SHA-256_SSWU_NU_, empty message).
+func TestMapToCurve(t *testing.T) {
+	g1 := new(G1)
+	u := testEncoding("156c8a6a2c184569d69a76be144b5cdc5141d2d2ca4fe341f011e25e3969c55ad9e9b9ce2eb833c81a908e5fa4ac5f03")
+	want := testEncoding(
+		"184bb665c37ff561a89ec2122dd343f20e0f4cbcaec84e3c3052ea81d1834e192c426074b02ed3dca4e7676ce4ce48ba",
+		"04407b8d35af4dacc809927071fc0405218f1401a6d15af775810e4e460064bcc9468beeba82fdc751be70476c888bf3",
+	)
+	if _, err := g1.MapToCurve(u); err != nil {
+		t.Fatalf("failed to map to G1: %v", err)
+	}
+	if have := g1.Marshal(); !bytes.Equal(have, want) {
+		t.Errorf("G1 mapping mismatch: have %x, want %x", have, want)
+	}
+	g2 := new(G2)
+	u = testEncoding(
+		"07355d25caf6e7f2f0cb2812ca0e513bd026ed09dda65b177500fa31714e09ea0ded3a078b526bed3307f804d4b93b04",
+		"02829ce3c021339ccb5caf3e187f6370e1e2a311dec9b75363117063ab2015603ff52c3d3b98f19c2f65575e99e8b78c",
+	)
+	want = testEncoding(
+		"00e7f4568a82b4b7dc1f14c6aaa055edf51502319c723c4dc2688c7fe5944c213f510328082396515734b6612c4e7bb7",
+		"126b855e9e69b1f691f816e48ac6977664d24d99f8724868a184186469ddfd4617367e94527d4b74fc86413483afb35b",
+		"0caead0fd7b6176c01436833c79d305c78be307da5f6af6c133c47311def6ff1e0babf57a0fb5539fce7ee12407b0a42",
+		"1498aadcf7ae2b345243e281ae076df6de84455d766ab6fcdaad71fab60abb2e8b980a440043cd305db09d283c895e3d",
+	)
+	if _, err := g2.MapToCurve(u); err != nil {
+		t.Fatalf("failed to map to G2: %v", err)
+	}
+	if have := g2.Marshal(); !bytes.Equal(have, want) {
+		t.Errorf("G2 mapping mismatch: have %x, want %x", have, want)
+	}
+	// Zero hits the exceptional case of the SWU map
+	if _, err := g1.MapToCurve(make([]byte, FpSize)); err != nil || !g1.InSubgroup() {
+		t.Errorf("failed to map zero to G1: %v", err)
+	}
+	if _, err := g2.MapToCurve(make([]byte, 2*FpSize)); err != nil || !g2.InSubgroup() {
+		t.Errorf("failed to map zero to G2: %v", err)
+	}
+	// Non-canonical field elements must be rejected
+	bad := make([]byte, FpSize)
+	marshalFp(bad, P)
+	if _, err := g1.MapToCurve(bad); err != ErrInvalidFieldElement {
+		t.Errorf("non-canonical element: have %v, want %v", err, ErrInvalidFieldElement)
+	}
+}
+
+// Tests that psi acts on G2 as multiplication by p, which the cofactor
+// clearing relies on.
+func TestPsi(t *testing.T) {
+	want := g2Gen.mul(new(big.Int).Mod(P, Order))
+	if !g2Gen.psi().equal(want) {
+		t.Errorf("psi mismatch")
+	}
+}
+
+func TestFieldArithmetic(t *testing.T) {
+	a, b := testFe12(3), testFe12(5)
+	if !a.mul(a.inv()).isOne() {
+		t.Errorf("inverse mismatch")
+	}
+	if !a.mul(b).equal(b.mul(a)) {
+		t.Errorf("multiplication not commutative")
+	}
+	if have, want := a.frobenius(), a.exp(P); !have.equal(want) {
+		t.Errorf("frobenius mismatch")
+	}
+}
+
+func TestGroupLaws(t *testing.T) {
+	a, b := big.NewInt(1234567), new(big.Int).Sub(Order, big.NewInt(89))
+	sum := new(big.Int).Add(a, b)
+
+	g1 := new(G1).Add(new(G1).ScalarBaseMult(a), new(G1).ScalarBaseMult(b))
+	if !bytes.Equal(g1.Marshal(), new(G1).ScalarBaseMult(sum).Marshal()) {
+		t.Errorf("G1 scalar multiplication not distributive")
+	}
+	if !new(G1).Add(g1, new(G1).Neg(g1)).IsInfinity() {
+		t.Errorf("G1 negation mismatch")
+	}
+	g2 := new(G2).Add(new(G2).ScalarBaseMult(a), new(G2).ScalarBaseMult(b))
+	if !bytes.Equal(g2.Marshal(), new(G2).ScalarBaseMult(sum).Marshal()) {
+		t.Errorf("G2 scalar multiplication not distributive")
+	}
+	if !new(G2).Add(g2, new(G2).Neg(g2)).IsInfinity() {
+		t.Errorf("G2 negation mismatch")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	g1 := new(G1).ScalarBaseMult(big.NewInt(42))
+	enc := g1.Marshal()
+	if rest, err := new(G1).Unmarshal(append(enc, 0xff)); err != nil || !bytes.Equal(rest, []byte{0xff}) {
+		t.Fatalf("failed to unmarshal G1: %v", err)
+	}
+	g2 := new(G2).ScalarBaseMult(big.NewInt(42))
+	dec := new(G2)
+	if _, err := dec.Unmarshal(g2.Marshal()); err != nil || !bytes.Equal(dec.Marshal(), g2.Marshal()) {
+		t.Fatalf("failed to unmarshal G2: %v", err)
+	}
+	if _, err := dec.Unmarshal(make([]byte, G2Size)); err != nil || !dec.IsInfinity() {
+		t.Errorf("failed to unmarshal infinity: %v", err)
+	}
+	// Corrupt encodings must be rejected
+	bad := append([]byte{}, enc...)
+	bad[0] = 1
+	if _, err := new(G1).Unmarshal(bad); err != ErrInvalidFieldElement {
+		t.Errorf("non-zero padding: have %v, want %v", err, ErrInvalidFieldElement)
+	}
+	bad = append([]byte{}, enc...)
+	bad[G1Size-1] ^= 1
+	if _, err := new(G1).Unmarshal(bad); err != ErrNotOnCurve {
+		t.Errorf("point off curve: have %v, want %v", err, ErrNotOnCurve)
+	}
+	bad = make([]byte, G1Size)
+	marshalFp(bad, P)
+	if _, err := new(G1).Unmarshal(bad); err != ErrInvalidFieldElement {
+		t.Errorf("non-canonical coordinate: have %v, want %v", err, ErrInvalidFieldElement)
+	}
+	if _, err := new(G1).Unmarshal(enc[1:]); err != errShortInput {
+		t.Errorf("short input: have %v, want %v", err, errShortInput)
+	}
+}
+
+func TestPairingBilinearity(t *testing.T) {
+	a, b := big.NewInt(7), big.NewInt(11)
+	ab := new(big.Int).Mul(a, b)
+
+	// e(a*P, b*Q) * e(-ab*P, Q) == 1
+	p := []*G1{new(G1).ScalarBaseMult(a), new(G1).Neg(new(G1).ScalarBaseMult(ab))}
+	q := []*G2{new(G2).ScalarBaseMult(b), G2Generator()}
+	if !PairingCheck(p, q) {
+		t.Errorf("pairing not bilinear")
+	}
+	// The pairing must not be degenerate
+	if PairingCheck(p[:1], q[:1]) {
+		t.Errorf("pairing degenerate")
+	}
+	// Pairs containing the identity are neutral
+	if !PairingCheck([]*G1{new(G1).ScalarBaseMult(new(big.Int))}, []*G2{G2Generator()}) {
+		t.Errorf("pairing with identity not neutral")
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import "math/big"
+
+// point is an affine point on a short Weierstrass curve y^2 = x^3 + b over
+// Fp2. Points of G1 live on the curve over the base field and are kept with
+// zero imaginary parts, so both groups share the same arithmetic.
+type point struct {
+	x, y fe2
+	inf  bool
+}
+
+var (
+	// Curve constants of E: y^2 = x^3 + 4 and its twist E': y^2 = x^3 + 4(u+1)
+	g1B = fe2FromFp(big.NewInt(4))
+	g2B = fe2{big.NewInt(4), big.NewInt(4)}
+
+	g1Gen = point{
+		x: fe2FromFp(bigFromHex("17f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb")),
+		y: fe2FromFp(bigFromHex("08b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1")),
+	}
+	g2Gen = point{
+		x: fe2{
+			bigFromHex("024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8"),
+			bigFromHex("13e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e"),
+		},
+		y: fe2{
+			bigFromHex("0ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801"),
+			bigFromHex("0606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be"),
+		},
+	}
+)
+
+func infinity() point { return point{x: fe2Zero(), y: fe2Zero(), inf: true} }
+
+// onCurve reports whether the point satisfies the curve equation with the
+// given b coefficient.
+func (p point) onCurve(b fe2) bool {
+	if p.inf {
+		return true
+	}
+	return p.y.square().equal(p.x.square().mul(p.x).add(b))
+}
+
+func (p point) equal(q point) bool {
+	if p.inf || q.inf {
+		return p.inf == q.inf
+	}
+	return p.x.equal(q.x) && p.y.equal(q.y)
+}
+
+func (p point) neg() point {
+	if p.inf {
+		return p
+	}
+	return point{x: p.x, y: p.y.neg()}
+}
+
+// tangent returns the slope of the tangent at p, which must not be a point of
+// order two.
+func (p point) tangent() fe2 {
+	x2 := p.x.square()
+	return x2.add(x2).add(x2).mul(p.y.add(p.y).inv())
+}
+
+// chord returns the slope of the line through p and q, which must have
+// different x coordinates.
+func (p point) chord(q point) fe2 {
+	return q.y.sub(p.y).mul(q.x.sub(p.x).inv())
+}
+
+// along returns the third intersection of the line with the given slope
+// through p and q with the curve, mirrored on the x axis.
+func along(p, q point, slope fe2) point {
+	x := slope.square().sub(p.x).sub(q.x)
+	return point{x: x, y: slope.mul(p.x.sub(x)).sub(p.y)}
+}
+
+func (p point) double() point {
+	if p.inf || p.y.isZero() {
+		return infinity()
+	}
+	return along(p, p, p.tangent())
+}
+
+func (p point) add(q point) point {
+	switch {
+	case p.inf:
+		return q
+	case q.inf:
+		return p
+	case p.x.equal(q.x):
+		if p.y.equal(q.y) {
+			return p.double()
+		}
+		return infinity()
+	}
+	return along(p, q, p.chord(q))
+}
+
+// mul returns k*p for a non-negative scalar.
+func (p point) mul(k *big.Int) point {
+	r := infinity()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = r.double()
+		if k.Bit(i) == 1 {
+			r = r.add(p)
+		}
+	}
+	return r
+}
+
+// inSubgroup reports whether the point is of order r.
+func (p point) inSubgroup() bool {
+	return p.mul(Order).inf
+}
@@ -0,0 +1,232 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import "math/big"
+
+func bigFromHex(hex string) *big.Int {
+	n, ok := new(big.Int).SetString(hex, 16)
+	if !ok {
+		panic("bls12381: invalid constant " + hex)
+	}
+	return n
+}
+
+var (
+	// P is the modulus of the base field.
+	P = bigFromHex("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab")
+
+	// Order is the order of the G1, G2 and GT groups.
+	Order = bigFromHex("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001")
+)
+
+// Arithmetic in the base field. Operands are expected to be reduced and are
+// never modified, every operation allocates its result.
+
+func fpAdd(a, b *big.Int) *big.Int {
+	r := new(big.Int).Add(a, b)
+	if r.Cmp(P) >= 0 {
+		r.Sub(r, P)
+	}
+	return r
+}
+
+func fpSub(a, b *big.Int) *big.Int {
+	r := new(big.Int).Sub(a, b)
+	if r.Sign() < 0 {
+		r.Add(r, P)
+	}
+	return r
+}
+
+func fpMul(a, b *big.Int) *big.Int {
+	r := new(big.Int).Mul(a, b)
+	return r.Mod(r, P)
+}
+
+func fpNeg(a *big.Int) *big.Int {
+	if a.Sign() == 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Sub(P, a)
+}
+
+func fpInv(a *big.Int) *big.Int {
+	return new(big.Int).ModInverse(a, P)
+}
+
+// fe2 is an element c0 + c1*u of the quadratic extension Fp2 = Fp[u]/(u^2+1).
+type fe2 struct {
+	c0, c1 *big.Int
+}
+
+func fe2Zero() fe2 { return fe2{new(big.Int), new(big.Int)} }
+func fe2One() fe2  { return fe2{big.NewInt(1), new(big.Int)} }
+
+// fe2FromFp embeds a base field element into Fp2.
+func fe2FromFp(a *big.Int) fe2 { return fe2{a, new(big.Int)} }
+
+func (a fe2) add(b fe2) fe2 { return fe2{fpAdd(a.c0, b.c0), fpAdd(a.c1, b.c1)} }
+func (a fe2) sub(b fe2) fe2 { return fe2{fpSub(a.c0, b.c0), fpSub(a.c1, b.c1)} }
+func (a fe2) neg() fe2      { return fe2{fpNeg(a.c0), fpNeg(a.c1)} }
+func (a fe2) conj() fe2     { return fe2{a.c0, fpNeg(a.c1)} }
+func (a fe2) square() fe2   { return a.mul(a) }
+
+func (a fe2) mul(b fe2) fe2 {
+	t0 := fpMul(a.c0, b.c0)
+	t1 := fpMul(a.c1, b.c1)
+	t2 := fpMul(fpAdd(a.c0, a.c1), fpAdd(b.c0, b.c1))
+	return fe2{fpSub(t0, t1), fpSub(fpSub(t2, t0), t1)}
+}
+
+// mulFp multiplies by an element of the base field.
+func (a fe2) mulFp(b *big.Int) fe2 { return fe2{fpMul(a.c0, b), fpMul(a.c1, b)} }
+
+// mulByNonResidue multiplies by the non-residue u+1 the higher extensions are
+// built with.
+func (a fe2) mulByNonResidue() fe2 { return fe2{fpSub(a.c0, a.c1), fpAdd(a.c0, a.c1)} }
+
+func (a fe2) inv() fe2 {
+	n := fpInv(fpAdd(fpMul(a.c0, a.c0), fpMul(a.c1, a.c1)))
+	return fe2{fpMul(a.c0, n), fpNeg(fpMul(a.c1, n))}
+}
+
+func (a fe2) exp(e *big.Int) fe2 {
+	r := fe2One()
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = r.square()
+		if e.Bit(i) == 1 {
+			r = r.mul(a)
+		}
+	}
+	return r
+}
+
+func (a fe2) isZero() bool     { return a.c0.Sign() == 0 && a.c1.Sign() == 0 }
+func (a fe2) equal(b fe2) bool { return a.c0.Cmp(b.c0) == 0 && a.c1.Cmp(b.c1) == 0 }
+
+// fe6 is an element c0 + c1*v + c2*v^2 of the cubic extension
+// Fp6 = Fp2[v]/(v^3-(u+1)).
+type fe6 struct {
+	c0, c1, c2 fe2
+}
+
+func fe6Zero() fe6 { return fe6{fe2Zero(), fe2Zero(), fe2Zero()} }
+func fe6One() fe6  { return fe6{fe2One(), fe2Zero(), fe2Zero()} }
+
+func (a fe6) add(b fe6) fe6 { return fe6{a.c0.add(b.c0), a.c1.add(b.c1), a.c2.add(b.c2)} }
+func (a fe6) sub(b fe6) fe6 { return fe6{a.c0.sub(b.c0), a.c1.sub(b.c1), a.c2.sub(b.c2)} }
+func (a fe6) neg() fe6      { return fe6{a.c0.neg(), a.c1.neg(), a.c2.neg()} }
+
+func (a fe6) mul(b fe6) fe6 {
+	t0 := a.c0.mul(b.c0)
+	t1 := a.c1.mul(b.c1)
+	t2 := a.c2.mul(b.c2)
+	return fe6{
+		t0.add(a.c1.mul(b.c2).add(a.c2.mul(b.c1)).mulByNonResidue()),
+		a.c0.mul(b.c1).add(a.c1.mul(b.c0)).add(t2.mulByNonResidue()),
+		a.c0.mul(b.c2).add(t1).add(a.c2.mul(b.c0)),
+	}
+}
+
+// mulByV multiplies by the generator v of the extension.
+func (a fe6) mulByV() fe6 { return fe6{a.c2.mulByNonResidue(), a.c0, a.c1} }
+
+func (a fe6) inv() fe6 {
+	t0 := a.c0.square().sub(a.c1.mul(a.c2).mulByNonResidue())
+	t1 := a.c2.square().mulByNonResidue().sub(a.c0.mul(a.c1))
+	t2 := a.c1.square().sub(a.c0.mul(a.c2))
+	n := a.c0.mul(t0).add(a.c2.mul(t1).add(a.c1.mul(t2)).mulByNonResidue()).inv()
+	return fe6{t0.mul(n), t1.mul(n), t2.mul(n)}
+}
+
+func (a fe6) equal(b fe6) bool {
+	return a.c0.equal(b.c0) && a.c1.equal(b.c1) && a.c2.equal(b.c2)
+}
+
+// fe12 is an element c0 + c1*w of the quadratic extension Fp12 = Fp6[w]/(w^2-v)
+// the GT group lives in.
+type fe12 struct {
+	c0, c1 fe6
+}
+
+func fe12One() fe12 { return fe12{fe6One(), fe6Zero()} }
+
+func (a fe12) mul(b fe12) fe12 {
+	t0 := a.c0.mul(b.c0)
+	t1 := a.c1.mul(b.c1)
+	t2 := a.c0.add(a.c1).mul(b.c0.add(b.c1))
+	return fe12{t0.add(t1.mulByV()), t2.sub(t0).sub(t1)}
+}
+
+func (a fe12) square() fe12 { return a.mul(a) }
+
+// conj returns a^(p^6), which is the inverse for elements of the cyclotomic
+// subgroup.
+func (a fe12) conj() fe12 { return fe12{a.c0, a.c1.neg()} }
+
+func (a fe12) inv() fe12 {
+	n := a.c0.mul(a.c0).sub(a.c1.mul(a.c1).mulByV()).inv()
+	return fe12{a.c0.mul(n), a.c1.mul(n).neg()}
+}
+
+func (a fe12) exp(e *big.Int) fe12 {
+	r := fe12One()
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = r.square()
+		if e.Bit(i) == 1 {
+			r = r.mul(a)
+		}
+	}
+	return r
+}
+
+// frobeniusCoeffs holds (u+1)^(k(p-1)/6) for the powers w^k, so that raising
+// an element to the power of p maps each Fp2 coefficient c of w^k to
+// conj(c)*(u+1)^(k(p-1)/6).
+var frobeniusCoeffs [6]fe2
+
+func init() {
+	e := new(big.Int).Sub(P, big.NewInt(1))
+	e.Div(e, big.NewInt(6))
+
+	nonResidue := fe2One().mulByNonResidue()
+	for k := range frobeniusCoeffs {
+		frobeniusCoeffs[k] = nonResidue.exp(new(big.Int).Mul(e, big.NewInt(int64(k))))
+	}
+}
+
+// frobenius returns a^p.
+func (a fe12) frobenius() fe12 {
+	// The coefficients of c0 belong to w^0, w^2, w^4 and those of c1 to
+	// w^1, w^3 and w^5.
+	return fe12{
+		fe6{
+			a.c0.c0.conj(),
+			a.c0.c1.conj().mul(frobeniusCoeffs[2]),
+			a.c0.c2.conj().mul(frobeniusCoeffs[4]),
+		},
+		fe6{
+			a.c1.c0.conj().mul(frobeniusCoeffs[1]),
+			a.c1.c1.conj().mul(frobeniusCoeffs[3]),
+			a.c1.c2.conj().mul(frobeniusCoeffs[5]),
+		},
+	}
+}
+
+func (a fe12) equal(b fe12) bool { return a.c0.equal(b.c0) && a.c1.equal(b.c1) }
+func (a fe12) isOne() bool       { return a.equal(fe12One()) }
@@ -0,0 +1,91 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import "math/big"
+
+// curveParam is the absolute value of the (negative) parameter x the curve is
+// generated from, which doubles as the Miller loop length of the ate pairing.
+const curveParam uint64 = 0xd201000000010000
+
+// hardExponent is the hard part (p^4 - p^2 + 1) / r of the final exponent.
+var hardExponent *big.Int
+
+func init() {
+	p2 := new(big.Int).Mul(P, P)
+	hardExponent = new(big.Int).Mul(p2, p2)
+	hardExponent.Sub(hardExponent, p2)
+	hardExponent.Add(hardExponent, big.NewInt(1))
+	hardExponent.Div(hardExponent, Order)
+}
+
+// line evaluates the line with the given slope through the twist point t at
+// the G1 point (xp, yp). The twist point is mapped onto the curve over Fp12 by
+// (x, y) -> (x/w^2, y/w^3), and the result is scaled by w^3 which lies in a
+// subfield and is thus eliminated by the final exponentiation.
+func line(slope fe2, t point, xp, yp *big.Int) fe12 {
+	return fe12{
+		fe6{slope.mul(t.x).sub(t.y), slope.mulFp(xp).neg(), fe2Zero()},
+		fe6{fe2Zero(), fe2FromFp(yp), fe2Zero()},
+	}
+}
+
+// miller runs the Miller loop of the optimal ate pairing on a G1 and a G2
+// point of prime order.
+func miller(p, q point) fe12 {
+	if p.inf || q.inf {
+		return fe12One()
+	}
+	var (
+		f      = fe12One()
+		t      = q
+		xp, yp = p.x.c0, p.y.c0
+	)
+	for i := 62; i >= 0; i-- {
+		slope := t.tangent()
+		f = f.square().mul(line(slope, t, xp, yp))
+		t = along(t, t, slope)
+
+		if curveParam>>uint(i)&1 == 1 {
+			slope := t.chord(q)
+			f = f.mul(line(slope, t, xp, yp))
+			t = along(t, q, slope)
+		}
+	}
+	// The curve parameter is negative
+	return f.conj()
+}
+
+// finalExponentiation raises f to the power of (p^12 - 1) / r.
+func finalExponentiation(f fe12) fe12 {
+	// Easy part: f^((p^6 - 1)(p^2 + 1))
+	f = f.conj().mul(f.inv())
+	f = f.frobenius().frobenius().mul(f)
+
+	return f.exp(hardExponent)
+}
+
+// PairingCheck calculates the optimal ate pairing for a set of points and
+// reports whether the product of the pairings is the identity. All points are
+// expected to be in their prime order subgroups.
+func PairingCheck(a []*G1, b []*G2) bool {
+	f := fe12One()
+	for i := range a {
+		f = f.mul(miller(a[i].p, b[i].p))
+	}
+	return finalExponentiation(f).isOne()
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -304,6 +305,37 @@ func (api *PrivateDebugAPI) Preimage(ctx context.Context, hash common.Hash) (hex
 	return nil, errors.New("unknown preimage")
 }
 
+// OpcodeCounts returns the number of times each opcode was executed by the EVM
+// since the node started, summed over all calls made while processing blocks
+// and serving requests. It is only available if opcode counting was enabled.
+func (api *PrivateDebugAPI) OpcodeCounts() (map[string]uint64, error) {
+	counter := api.eth.blockchain.GetVMConfig().OpcodeCounter
+	if counter == nil {
+		return nil, errors.New("opcode counting disabled")
+	}
+	return counter.Counts(), nil
+}
+
+// OpcodeCountsTransaction re-executes the given transaction and returns the
+// number of times each opcode was executed by it. Unlike OpcodeCounts it does
+// not need opcode counting to be enabled on the node.
+func (api *PrivateDebugAPI) OpcodeCountsTransaction(ctx context.Context, hash common.Hash) (map[string]uint64, error) {
+	tx, blockHash, _, index := rawdb.ReadTransaction(api.eth.ChainDb(), hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %#x not found", hash)
+	}
+	msg, vmctx, statedb, err := api.computeTxEnv(blockHash, int(index), defaultTraceReexec)
+	if err != nil {
+		return nil, err
+	}
+	counter := vm.NewOpcodeCounter()
+	vmenv := vm.NewEVM(vmctx, statedb, api.eth.blockchain.Config(), vm.Config{OpcodeCounter: counter})
+	if _, _, _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas())); err != nil {
+		return nil, fmt.Errorf("execution failed: %v", err)
+	}
+	return counter.Counts(), nil
+}
+
 // BadBlockArgs represents the entries in the list returned when bad blocks are queried.
 type BadBlockArgs struct {
 	Hash  common.Hash            `json:"hash"`
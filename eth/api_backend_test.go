@@ -0,0 +1,114 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/ewasm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// wasmRefuser stands in for an ewasm engine, failing to run any wasm code.
+type wasmRefuser struct{}
+
+func (wasmRefuser) Run(contract *vm.Contract, input []byte, static bool) ([]byte, error) {
+	return nil, errors.New("wasm execution unavailable")
+}
+
+func (wasmRefuser) CanRun(code []byte) bool {
+	return vm.CodeFormat(code) == vm.FormatWasm
+}
+
+// Tests that simulated deployments report the would-be address in every case,
+// along with the deposited code or the reason the deployment failed.
+func TestSimulateDeploy(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		sender = common.HexToAddress("0x5e4d")
+		config = *params.TestChainConfig
+	)
+	config.EWASMBlock = new(big.Int)
+	gspec := &core.Genesis{
+		Config:   &config,
+		GasLimit: 8000000,
+		Alloc:    core.GenesisAlloc{sender: {Balance: new(big.Int), Nonce: 5}},
+	}
+	gspec.MustCommit(db)
+	vmConfig := vm.Config{Interpreters: []vm.InterpreterFactory{func(evm *vm.EVM, cfg vm.Config) vm.Interpreter {
+		return wasmRefuser{}
+	}}}
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vmConfig, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	api := ethapi.NewPublicBlockChainAPI(&EthAPIBackend{eth: &Ethereum{blockchain: chain, config: &Config{}}})
+	address := crypto.CreateAddress(sender, 5)
+
+	tests := []struct {
+		initcode string
+		code     string
+		err      string
+	}{
+		// Deposit the single byte 0x2a
+		{"602a60005360016000f3", "2a", ""},
+		// Revert right away
+		{"60006000fd", "", "evm: execution reverted"},
+		// Deposit a truncated wasm module, rejected by the sentinel contract
+		{"680061736d010000000160005260096017f3", "", ewasm.ErrUnexpectedEOF.Error()},
+		// Truncated wasm init code, rejected before running
+		{"0061736d0100000001", "", ewasm.ErrUnexpectedEOF.Error()},
+		// Handle the rejection of a nested creation, then deposit 0x2a
+		{"680061736d0100000001600052600960176000f050602a60005360016000f3", "2a", ""},
+		// Handle the rejection of a nested creation, then revert
+		{"680061736d0100000001600052600960176000f05060006000fd", "", "evm: execution reverted"},
+	}
+	for i, tt := range tests {
+		data := hexutil.Bytes(common.FromHex(tt.initcode))
+		result, err := api.SimulateDeploy(context.Background(), ethapi.CallArgs{From: &sender, Data: &data}, rpc.LatestBlockNumber)
+		if err != nil {
+			t.Errorf("test %d: failed to simulate: %v", i, err)
+			continue
+		}
+		if result.Address != address {
+			t.Errorf("test %d: address mismatch: have %x, want %x", i, result.Address, address)
+		}
+		if result.Error != tt.err {
+			t.Errorf("test %d: error mismatch: have %q, want %q", i, result.Error, tt.err)
+		}
+		if code := common.Bytes2Hex(result.Code); code != tt.code {
+			t.Errorf("test %d: code mismatch: have %s, want %s", i, code, tt.code)
+		}
+		if result.GasUsed == 0 {
+			t.Errorf("test %d: no gas used", i)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package eth
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"math/big"
 	"reflect"
@@ -26,9 +27,14 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 var dumper = spew.ConfigState{Indent: "    "}
@@ -245,3 +251,53 @@ func TestStorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+// Tests the opcode counting debug APIs against a chain with a single contract
+// call.
+func TestOpcodeCounts(t *testing.T) {
+	var (
+		db       = rawdb.NewMemoryDatabase()
+		contract = common.HexToAddress("0xc0de")
+		gspec    = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				testBank: {Balance: big.NewInt(1000000000)},
+				// PUSH1 1, PUSH1 0, MSTORE, STOP
+				contract: {Code: common.Hex2Bytes("600160005200"), Balance: new(big.Int)},
+			},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.HomesteadSigner{}
+	)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 1, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(testBank), contract, new(big.Int), 100000, new(big.Int), nil), signer, testBankKey)
+		b.AddTx(tx)
+	})
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{OpcodeCounter: vm.NewOpcodeCounter()}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	api := NewPrivateDebugAPI(&Ethereum{blockchain: chain, chainDb: db})
+	want := map[string]uint64{"PUSH1": 2, "MSTORE": 1, "STOP": 1}
+
+	if have, err := api.OpcodeCounts(); err != nil || !reflect.DeepEqual(have, want) {
+		t.Errorf("node counts mismatch: have %v, want %v (err %v)", have, want, err)
+	}
+	have, err := api.OpcodeCountsTransaction(context.Background(), blocks[0].Transactions()[0].Hash())
+	if err != nil || !reflect.DeepEqual(have, want) {
+		t.Errorf("transaction counts mismatch: have %v, want %v (err %v)", have, want, err)
+	}
+	if _, err := api.OpcodeCountsTransaction(context.Background(), common.Hash{}); err == nil {
+		t.Errorf("unknown transaction counted")
+	}
+	// Counting is opt-in for the node-wide totals
+	uncounted, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer uncounted.Stop()
+	if _, err := NewPrivateDebugAPI(&Ethereum{blockchain: uncounted, chainDb: db}).OpcodeCounts(); err == nil {
+		t.Errorf("counts reported with counting disabled")
+	}
+}
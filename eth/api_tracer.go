@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"runtime"
 	"sync"
@@ -780,6 +781,114 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 	}
 }
 
+// createdContract is a contract deployed while executing a transaction.
+type createdContract struct {
+	Address  common.Address `json:"address"`
+	CodeHash common.Hash    `json:"codeHash"`
+}
+
+// createTracer is an EVM tracer collecting the addresses of all the contracts a
+// transaction deploys, either by being a creation itself or through the CREATE
+// and CREATE2 opcodes.
+//
+// The outcome of every call and creation is read off the stack once the frame
+// issuing it resumes, so creations only count if they and all the frames
+// enclosing them succeeded.
+type createTracer struct {
+	frames  []*createFrame   // Calls and creations awaiting their outcome
+	created []common.Address // Contracts deployed by the transaction, once it succeeded
+}
+
+// createFrame is a call or creation the create tracer awaits the outcome of.
+type createFrame struct {
+	depth   int              // Depth of the frame issuing the call, resuming once it's done
+	create  bool             // Whether the frame creates a contract
+	address common.Address   // Address of the top-level contract creation
+	created []common.Address // Contracts deployed within the frame so far
+}
+
+func (t *createTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.frames = []*createFrame{{create: create, address: to}}
+	return nil
+}
+
+// CaptureState settles the calls and creations the current frame issued once
+// it resumes, and starts awaiting the next ones.
+func (t *createTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	for len(t.frames) > 1 {
+		frame := t.frames[len(t.frames)-1]
+		if frame.depth < depth {
+			break
+		}
+		t.frames = t.frames[:len(t.frames)-1]
+
+		// Frames issued deeper than the current one belong to a failed frame
+		// which never resumed, the rest failed if they pushed zero
+		if frame.depth > depth || stack.Back(0).Sign() == 0 {
+			continue
+		}
+		parent := t.frames[len(t.frames)-1]
+		if frame.create {
+			parent.created = append(parent.created, common.BigToAddress(stack.Back(0)))
+		}
+		parent.created = append(parent.created, frame.created...)
+	}
+	switch op {
+	case vm.CREATE, vm.CREATE2:
+		t.frames = append(t.frames, &createFrame{depth: depth, create: true})
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.frames = append(t.frames, &createFrame{depth: depth})
+	}
+	return nil
+}
+
+func (t *createTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd keeps the contracts deployed by the transaction if it succeeded.
+func (t *createTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	if err != nil || len(t.frames) == 0 {
+		return nil
+	}
+	root := t.frames[0]
+	if root.create {
+		t.created = append(t.created, root.address)
+	}
+	t.created = append(t.created, root.created...)
+	return nil
+}
+
+// deployed returns the contracts the transaction deployed along with their code
+// hashes in the given post-transaction state, in the order they were created.
+func (t *createTracer) deployed(statedb *state.StateDB) []createdContract {
+	contracts := make([]createdContract, 0, len(t.created))
+	for _, addr := range t.created {
+		contracts = append(contracts, createdContract{Address: addr, CodeHash: statedb.GetCodeHash(addr)})
+	}
+	return contracts
+}
+
+// TraceCreatedContracts re-executes the given transaction and returns all the
+// contracts it deployed along with the hashes of their code, including the ones
+// created by other contracts, which are not reported in the receipt.
+func (api *PrivateDebugAPI) TraceCreatedContracts(ctx context.Context, hash common.Hash) ([]createdContract, error) {
+	tx, blockHash, _, index := rawdb.ReadTransaction(api.eth.ChainDb(), hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %#x not found", hash)
+	}
+	msg, vmctx, statedb, err := api.computeTxEnv(blockHash, int(index), defaultTraceReexec)
+	if err != nil {
+		return nil, err
+	}
+	tracer := new(createTracer)
+	vmenv := vm.NewEVM(vmctx, statedb, api.eth.blockchain.Config(), vm.Config{Debug: true, Tracer: tracer})
+	if _, _, _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas())); err != nil {
+		return nil, fmt.Errorf("tracing failed: %v", err)
+	}
+	return tracer.deployed(statedb), nil
+}
+
 // computeTxEnv returns the execution environment of a certain transaction.
 func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, reexec uint64) (core.Message, vm.Context, *state.StateDB, error) {
 	// Create the parent state database
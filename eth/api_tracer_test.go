@@ -0,0 +1,130 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the create tracer finds the contracts deployed by both creation
+// opcodes, and skips the creations that failed or were rolled back.
+func TestCreateTracer(t *testing.T) {
+	var (
+		initcode = common.Hex2Bytes("600160005360016000f3")     // deploys 0x01
+		store    = "69" + common.Bytes2Hex(initcode) + "600052" // store the initcode at 22
+		create   = "600a60166000f050"                           // CREATE(0, 22, 10)
+		revert   = "60006000fd"                                 // REVERT(0, 0)
+
+		contract   = common.BytesToAddress([]byte("contract"))
+		created    = crypto.CreateAddress(contract, 0)
+		created2   = crypto.CreateAddress2(contract, common.HexToHash("0x2a"), crypto.Keccak256(initcode))
+		deployed   = crypto.Keccak256Hash([]byte{0x01})
+		homestead  = &params.ChainConfig{ChainID: big.NewInt(1), HomesteadBlock: new(big.Int)}
+		reverter   = common.BytesToAddress([]byte("reverter"))
+		collisions = func(statedb *state.StateDB) { statedb.SetNonce(created, 1) }
+		reverters  = func(statedb *state.StateDB) { statedb.SetCode(reverter, common.Hex2Bytes(store+create+revert)) }
+	)
+	tests := []struct {
+		config  *params.ChainConfig
+		prepare func(*state.StateDB)
+		code    string
+		failed  bool
+		want    []createdContract
+	}{
+		// Deploy through both opcodes, with a failing creation running INVALID in between
+		{
+			code: store + create +
+				"60fe60405360016040" + "6000f050" + // CREATE(0, 64, 1)
+				"602a600a60166000f550", // CREATE2(0, 22, 10, 42)
+			want: []createdContract{{Address: created, CodeHash: deployed}, {Address: created2, CodeHash: deployed}},
+		},
+		// Deploy empty code before EIP-158, which leaves the nonce of the contract at zero
+		{
+			config: homestead,
+			code:   "600060006000f050", // CREATE(0, 0, 0)
+			want:   []createdContract{{Address: created, CodeHash: crypto.Keccak256Hash(nil)}},
+		},
+		// Create at an address which already has a nonce
+		{
+			prepare: collisions,
+			code:    store + create,
+			want:    []createdContract{},
+		},
+		// Create, then revert the transaction
+		{
+			code:   store + create + revert,
+			failed: true,
+			want:   []createdContract{},
+		},
+		// Call a contract which creates, then reverts, and deploy after it
+		{
+			prepare: reverters,
+			code:    "6000600060006000600073" + common.Bytes2Hex(reverter.Bytes()) + "5af150" + store + create,
+			want:    []createdContract{{Address: created, CodeHash: deployed}},
+		},
+	}
+	for i, tt := range tests {
+		config := tt.config
+		if config == nil {
+			config = params.AllEthashProtocolChanges
+		}
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+		if tt.prepare != nil {
+			tt.prepare(statedb)
+		}
+		tracer := new(createTracer)
+		_, _, err := runtime.Execute(common.Hex2Bytes(tt.code), nil, &runtime.Config{
+			ChainConfig: config,
+			State:       statedb,
+			EVMConfig:   vm.Config{Debug: true, Tracer: tracer},
+		})
+		if (err != nil) != tt.failed {
+			t.Fatalf("test %d: execution error mismatch: have %v, want failure %v", i, err, tt.failed)
+		}
+		if have := tracer.deployed(statedb); !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("test %d: created contracts mismatch: have %+v, want %+v", i, have, tt.want)
+		}
+	}
+	// Deploy through a top-level creation, which itself creates a contract
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	tracer := new(createTracer)
+	_, address, _, err := runtime.Create(common.Hex2Bytes(store+create+"60006000f3"), &runtime.Config{
+		ChainConfig: params.AllEthashProtocolChanges,
+		State:       statedb,
+		EVMConfig:   vm.Config{Debug: true, Tracer: tracer},
+	})
+	if err != nil {
+		t.Fatalf("creation failed: %v", err)
+	}
+	want := []createdContract{
+		{Address: address, CodeHash: crypto.Keccak256Hash(nil)},
+		{Address: crypto.CreateAddress(address, 1), CodeHash: deployed},
+	}
+	if have := tracer.deployed(statedb); !reflect.DeepEqual(have, want) {
+		t.Errorf("top-level created contracts mismatch: have %+v, want %+v", have, want)
+	}
+}
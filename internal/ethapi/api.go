@@ -36,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -762,17 +763,28 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNr rpc.BlockNumb
 	if state == nil || err != nil {
 		return nil, 0, false, err
 	}
-	// Set sender address or use a default if none specified
-	var addr common.Address
-	if args.From == nil {
-		if wallets := b.AccountManager().Wallets(); len(wallets) > 0 {
-			if accounts := wallets[0].Accounts(); len(accounts) > 0 {
-				addr = accounts[0].Address
-			}
+	return doCall(ctx, b, args, state, header, vmCfg, timeout, globalGasCap)
+}
+
+// callSender returns the sender of the given call, defaulting to the first
+// account of the node if none is specified.
+func callSender(b Backend, args CallArgs) common.Address {
+	if args.From != nil {
+		return *args.From
+	}
+	if wallets := b.AccountManager().Wallets(); len(wallets) > 0 {
+		if accounts := wallets[0].Accounts(); len(accounts) > 0 {
+			return accounts[0].Address
 		}
-	} else {
-		addr = *args.From
 	}
+	return common.Address{}
+}
+
+// doCall executes the given call on top of the given state, which it modifies.
+// A tracer configured through vmCfg is run along with the execution.
+func doCall(ctx context.Context, b Backend, args CallArgs, state *state.StateDB, header *types.Header, vmCfg vm.Config, timeout time.Duration, globalGasCap *big.Int) ([]byte, uint64, bool, error) {
+	addr := callSender(b, args)
+
 	// Set default gas & gas price if none were set
 	gas := uint64(math.MaxUint64 / 2)
 	if args.Gas != nil {
@@ -813,7 +825,11 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNr rpc.BlockNumb
 	defer cancel()
 
 	// Get a new instance of the EVM.
-	evm, vmError, err := b.GetEVM(ctx, msg, state, header)
+	var tracer vm.Tracer
+	if vmCfg.Debug {
+		tracer = vmCfg.Tracer
+	}
+	evm, vmError, err := b.GetEVM(ctx, msg, state, header, tracer)
 	if err != nil {
 		return nil, 0, false, err
 	}
@@ -902,6 +918,105 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	return DoEstimateGas(ctx, s.b, args, rpc.PendingBlockNumber, s.b.RPCGasCap())
 }
 
+// DeployResult is the outcome of a simulated contract deployment.
+type DeployResult struct {
+	Address  common.Address `json:"address"`
+	Code     hexutil.Bytes  `json:"code"`
+	CodeHash common.Hash    `json:"codeHash"`
+	Format   string         `json:"format"`
+	GasUsed  hexutil.Uint64 `json:"gasUsed"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// SimulateDeploy executes the given initcode as a contract creation on top of
+// the state of the given block, without submitting a transaction. It returns
+// the address the contract would be deployed to along with the code that would
+// be deposited there. Deployments rejected by the EVM, the ewasm sentinel or the
+// state transition are reported in the error field, mirroring what a failed
+// transaction would do.
+func (s *PublicBlockChainAPI) SimulateDeploy(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (*DeployResult, error) {
+	if args.To != nil {
+		return nil, errors.New("deployment must not specify a recipient")
+	}
+	if args.Data == nil || len(*args.Data) == 0 {
+		return nil, errors.New("missing initcode")
+	}
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	from := callSender(s.b, args)
+	args.From = &from
+
+	// Deployments may use up to the gas limit of the block by default
+	if args.Gas == nil {
+		gas := hexutil.Uint64(header.GasLimit)
+		args.Gas = &gas
+	}
+	result := &DeployResult{
+		Address: crypto.CreateAddress(from, state.GetNonce(from)),
+		Format:  vm.FormatLegacy.String(),
+	}
+	tracer := new(deployTracer)
+	_, gas, failed, err := doCall(ctx, s.b, args, state, header, vm.Config{Debug: true, Tracer: tracer}, 5*time.Second, s.b.RPCGasCap())
+	result.GasUsed = hexutil.Uint64(gas)
+	switch {
+	case err != nil:
+		result.Error = err.Error()
+		return result, nil
+	case failed:
+		if tracer.err == nil {
+			tracer.err = errors.New("deployment failed")
+		}
+		result.Error = tracer.err.Error()
+		return result, nil
+	}
+	code := state.GetCode(result.Address)
+	result.Code = code
+	result.CodeHash = crypto.Keccak256Hash(code)
+	if s.b.ChainConfig().IsEWASM(header.Number) {
+		result.Format = vm.CodeFormat(code).String()
+	}
+	return result, nil
+}
+
+// deployTracer keeps hold of the error a simulated deployment failed with, as
+// the state transition only tells whether it failed.
+type deployTracer struct {
+	started bool  // Whether the top-level creation got past validating its init code
+	err     error // Error the top-level creation or the validation of its init code ran into
+}
+
+func (t *deployTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.started = true
+	return nil
+}
+
+func (t *deployTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *deployTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd records the outcome of the top-level creation, which includes the
+// rejection of the code it deployed.
+func (t *deployTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	t.err = err
+	return nil
+}
+
+// CaptureCodeValidation records the rejection of the top-level wasm init code,
+// which aborts the creation before it even started. Validations past that
+// point belong to nested creations or are reported through CaptureEnd.
+func (t *deployTracer) CaptureCodeValidation(codeHash common.Hash, valid bool, err error, d time.Duration) error {
+	if !t.started {
+		t.err = err
+	}
+	return nil
+}
+
 // ExecutionResult groups all structured logs emitted by the EVM
 // while replaying a transaction in debug mode as well as transaction
 // execution status, the amount of gas used and the return value
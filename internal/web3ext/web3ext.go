@@ -417,6 +417,21 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new web3._extend.Method({
+			name: 'traceCreatedContracts',
+			call: 'debug_traceCreatedContracts',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'opcodeCounts',
+			call: 'debug_opcodeCounts',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'opcodeCountsTransaction',
+			call: 'debug_opcodeCountsTransaction',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'preimage',
 			call: 'debug_preimage',
@@ -516,6 +531,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.utils.toHex]
 		}),
+		new web3._extend.Method({
+			name: 'simulateDeploy',
+			call: 'eth_simulateDeploy',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getProof',
 			call: 'eth_getProof',
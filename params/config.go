@@ -209,16 +209,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -288,6 +288,8 @@ type ChainConfig struct {
 	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"` // Constantinople switch block (nil = no fork, 0 = already activated)
 	PetersburgBlock     *big.Int `json:"petersburgBlock,omitempty"`     // Petersburg switch block (nil = same as Constantinople)
 	EWASMBlock          *big.Int `json:"ewasmBlock,omitempty"`          // EWASM switch block (nil = no fork, 0 = already activated)
+	EIP2537Block        *big.Int `json:"eip2537Block,omitempty"`        // EIP2537 (BLS12-381 precompiles) switch block (nil = no fork, 0 = already activated)
+	EIP3855Block        *big.Int `json:"eip3855Block,omitempty"`        // EIP3855 (PUSH0 instruction) switch block (nil = no fork, 0 = already activated)
 
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
@@ -386,6 +388,16 @@ func (c *ChainConfig) IsEWASM(num *big.Int) bool {
 	return isForked(c.EWASMBlock, num)
 }
 
+// IsEIP2537 returns whether num is either equal to the EIP2537 fork block or greater.
+func (c *ChainConfig) IsEIP2537(num *big.Int) bool {
+	return isForked(c.EIP2537Block, num)
+}
+
+// IsEIP3855 returns whether num is either equal to the EIP3855 fork block or greater.
+func (c *ChainConfig) IsEIP3855(num *big.Int) bool {
+	return isForked(c.EIP3855Block, num)
+}
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
@@ -405,6 +417,51 @@ func (c *ChainConfig) GasTable(num *big.Int) GasTable {
 	}
 }
 
+// CheckConfigForkOrder checks that no fork is scheduled before a fork it builds
+// upon. The mainline forks must be activated in order, while the experimental
+// ones extend the rules of a specific mainline fork and must not precede it.
+func (c *ChainConfig) CheckConfigForkOrder() error {
+	type fork struct {
+		name  string
+		block *big.Int
+	}
+	var lastFork fork
+	for _, cur := range []fork{
+		{"homesteadBlock", c.HomesteadBlock},
+		{"eip150Block", c.EIP150Block},
+		{"eip155Block", c.EIP155Block},
+		{"eip158Block", c.EIP158Block},
+		{"byzantiumBlock", c.ByzantiumBlock},
+		{"constantinopleBlock", c.ConstantinopleBlock},
+	} {
+		if lastFork.name != "" {
+			if lastFork.block == nil && cur.block != nil {
+				return fmt.Errorf("unsupported fork ordering: %v not enabled, but %v enabled at %v",
+					lastFork.name, cur.name, cur.block)
+			}
+			if lastFork.block != nil && cur.block != nil && lastFork.block.Cmp(cur.block) > 0 {
+				return fmt.Errorf("unsupported fork ordering: %v enabled at %v, but %v enabled at %v",
+					lastFork.name, lastFork.block, cur.name, cur.block)
+			}
+		}
+		lastFork = cur
+	}
+	for _, dep := range []struct{ cur, base fork }{
+		{fork{"ewasmBlock", c.EWASMBlock}, fork{"byzantiumBlock", c.ByzantiumBlock}},
+		{fork{"eip2537Block", c.EIP2537Block}, fork{"byzantiumBlock", c.ByzantiumBlock}},
+		{fork{"eip3855Block", c.EIP3855Block}, fork{"constantinopleBlock", c.ConstantinopleBlock}},
+	} {
+		if dep.cur.block == nil {
+			continue
+		}
+		if dep.base.block == nil || dep.base.block.Cmp(dep.cur.block) > 0 {
+			return fmt.Errorf("unsupported fork ordering: %v enabled at %v, but %v enabled at %v",
+				dep.cur.name, dep.cur.block, dep.base.name, dep.base.block)
+		}
+	}
+	return nil
+}
+
 // CheckCompatible checks whether scheduled fork transitions have been imported
 // with a mismatching chain configuration.
 func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
@@ -457,6 +514,12 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
+	if isForkIncompatible(c.EIP2537Block, newcfg.EIP2537Block, head) {
+		return newCompatError("EIP2537 fork block", c.EIP2537Block, newcfg.EIP2537Block)
+	}
+	if isForkIncompatible(c.EIP3855Block, newcfg.EIP3855Block, head) {
+		return newCompatError("EIP3855 fork block", c.EIP3855Block, newcfg.EIP3855Block)
+	}
 	return nil
 }
 
@@ -524,6 +587,7 @@ type Rules struct {
 	ChainID                                     *big.Int
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158   bool
 	IsByzantium, IsConstantinople, IsPetersburg bool
+	IsEWASM, IsEIP2537, IsEIP3855               bool
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -541,5 +605,8 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 		IsByzantium:      c.IsByzantium(num),
 		IsConstantinople: c.IsConstantinople(num),
 		IsPetersburg:     c.IsPetersburg(num),
+		IsEWASM:          c.IsEWASM(num),
+		IsEIP2537:        c.IsEIP2537(num),
+		IsEIP3855:        c.IsEIP3855(num),
 	}
 }
@@ -79,3 +79,23 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckConfigForkOrder(t *testing.T) {
+	tests := []struct {
+		config *ChainConfig
+		valid  bool
+	}{
+		{AllEthashProtocolChanges, true},
+		{MainnetChainConfig, true},
+		{&ChainConfig{HomesteadBlock: big.NewInt(10), EIP150Block: big.NewInt(5)}, false},
+		{&ChainConfig{EIP150Block: big.NewInt(5)}, false},
+		{&ChainConfig{HomesteadBlock: big.NewInt(0), EIP150Block: big.NewInt(0), EIP155Block: big.NewInt(0), EIP158Block: big.NewInt(0), ByzantiumBlock: big.NewInt(0), EWASMBlock: big.NewInt(0)}, true},
+		{&ChainConfig{HomesteadBlock: big.NewInt(0), EIP150Block: big.NewInt(0), EIP155Block: big.NewInt(0), EIP158Block: big.NewInt(0), ByzantiumBlock: big.NewInt(10), EIP2537Block: big.NewInt(5)}, false},
+		{&ChainConfig{HomesteadBlock: big.NewInt(0), EIP150Block: big.NewInt(0), EIP155Block: big.NewInt(0), EIP158Block: big.NewInt(0), ByzantiumBlock: big.NewInt(0), EIP3855Block: big.NewInt(0)}, false},
+	}
+	for i, tt := range tests {
+		if err := tt.config.CheckConfigForkOrder(); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}
@@ -85,6 +85,23 @@ const (
 	Bn256ScalarMulGas       uint64 = 40000  // Gas needed for an elliptic curve scalar multiplication
 	Bn256PairingBaseGas     uint64 = 100000 // Base price for an elliptic curve pairing check
 	Bn256PairingPerPointGas uint64 = 80000  // Per-point price for an elliptic curve pairing check
+	SentinelBaseGas         uint64 = 1000   // Base price for validating and metering an ewasm module
+	SentinelPerWordGas      uint64 = 12     // Per-word price for validating and metering an ewasm module
+	BignumMul256Gas         uint64 = 5      // Price of the 256 bit multiplication precompile
+	BignumMulMod256Gas      uint64 = 8      // Price of the 256 bit modular multiplication precompile
+	BignumAddMod256Gas      uint64 = 8      // Price of the 256 bit modular addition precompile
+
+	// The BLS12-381 prices follow the measured cost of the math/big backed
+	// implementation in crypto/bls12381, at the rate ECRECOVER is priced at,
+	// instead of the EIP-2537 schedule meant for optimized libraries.
+	Bls12381G1AddGas          uint64 = 600     // Gas needed for a BLS12-381 G1 point addition
+	Bls12381G1MulGas          uint64 = 200000  // Gas needed for a BLS12-381 G1 scalar multiplication
+	Bls12381G2AddGas          uint64 = 4500    // Gas needed for a BLS12-381 G2 point addition
+	Bls12381G2MulGas          uint64 = 300000  // Gas needed for a BLS12-381 G2 scalar multiplication
+	Bls12381PairingBaseGas    uint64 = 2500000 // Base price for a BLS12-381 pairing check
+	Bls12381PairingPerPairGas uint64 = 420000  // Per-pair price for a BLS12-381 pairing check
+	Bls12381MapG1Gas          uint64 = 22000   // Gas needed for mapping a field element to a BLS12-381 G1 point
+	Bls12381MapG2Gas          uint64 = 110000  // Gas needed for mapping a field element to a BLS12-381 G2 point
 )
 
 var (